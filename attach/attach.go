@@ -0,0 +1,280 @@
+package attach
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DefaultMaxFileBytes  = 256 * 1024      // 默认单个文件的最大字节数
+	DefaultMaxTotalBytes = 1024 * 1024     // 默认一次提问附加内容的最大字节数
+	DefaultMaxFiles      = 100             // 默认一次提问最多附加的文件数
+	DefaultMaxImageBytes = 5 * 1024 * 1024 // 默认单张图片的最大字节数
+)
+
+// imageTypes 支持作为图片附加的扩展名与 MIME 类型
+var imageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// refPattern 匹配行首或空白之后的 @ 引用
+var refPattern = regexp.MustCompile(`(^|\s)@(\S+)`)
+
+// Options 附件展开选项
+type Options struct {
+	MaxFileBytes  int      // 单个文件的最大字节数，<= 0 时使用默认值
+	MaxTotalBytes int      // 附加内容的最大总字节数，<= 0 时使用默认值
+	MaxFiles      int      // 最多附加的文件数，<= 0 时使用默认值
+	MaxImageBytes int      // 单张图片的最大字节数，<= 0 时使用默认值
+	Ignore        []string // 额外的忽略规则，语法与 .gitignore 相同
+}
+
+// Image 直接引用的图片文件
+type Image struct {
+	Path string // 图片路径
+	MIME string // 图片的 MIME 类型
+	Data []byte // 图片数据
+}
+
+// Result 附件展开结果
+type Result struct {
+	Content    string   // 附加了文件内容的完整提问
+	Files      []string // 已附加的文件
+	Images     []Image  // 直接引用的图片，由调用方以多模态内容发送
+	Skipped    []string // 被跳过的文件及原因
+	TotalBytes int      // 附加内容（文件与图片）的总字节数
+}
+
+// expander 单次展开过程的状态
+type expander struct {
+	opts   Options
+	result *Result
+	seen   map[string]bool
+	body   strings.Builder
+}
+
+// Expand 展开提问中的 @文件 与 @目录/ 引用，将文件内容附加在提问之后。
+// 目录会被递归展开，遵循 .gitignore 规则并跳过二进制文件与超出大小限制的文件。
+// 直接引用的图片文件不会展开为文本，而是放入 Result.Images。
+// 引用的路径不存在时保持原样，不视为附件。
+// param prompt 为用户输入的提问。
+// param opts 为展开选项。
+//
+// return 展开结果和可能的错误。
+func Expand(prompt string, opts Options) (*Result, error) {
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = DefaultMaxFileBytes
+	}
+	if opts.MaxTotalBytes <= 0 {
+		opts.MaxTotalBytes = DefaultMaxTotalBytes
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+	if opts.MaxImageBytes <= 0 {
+		opts.MaxImageBytes = DefaultMaxImageBytes
+	}
+
+	e := &expander{
+		opts:   opts,
+		result: &Result{Content: prompt},
+		seen:   make(map[string]bool),
+	}
+
+	for _, m := range refPattern.FindAllStringSubmatch(prompt, -1) {
+		ref := strings.TrimRight(m[2], ",.;:!?，。；：！？")
+		path := expandHome(ref)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			if err := e.addDir(path); err != nil {
+				return nil, err
+			}
+		} else if mime, ok := imageTypes[strings.ToLower(filepath.Ext(path))]; ok {
+			e.addImage(path, mime, info.Size())
+		} else {
+			e.addFile(path, info.Size())
+		}
+	}
+
+	if e.body.Len() > 0 {
+		e.result.Content = prompt + "\n\n以下是引用的文件内容：\n" + e.body.String()
+	}
+	return e.result, nil
+}
+
+// addDir 递归附加目录中的文件
+func (e *expander) addDir(dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("解析目录路径失败 %s: %w", dir, err)
+	}
+	matcher := newIgnoreMatcher(absDir, e.opts.Ignore)
+
+	var paths []string
+	err = filepath.WalkDir(absDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path == absDir {
+			return nil
+		}
+		if d.IsDir() {
+			if matcher.ignored(path, true) {
+				return filepath.SkipDir
+			}
+			matcher.load(path)
+			return nil
+		}
+		if d.Type().IsRegular() && !matcher.ignored(path, false) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("遍历目录失败 %s: %w", dir, err)
+	}
+
+	sort.Strings(paths)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		rel, relErr := filepath.Rel(absDir, path)
+		if relErr != nil {
+			rel = path
+		}
+		e.addFile(filepath.Join(dir, rel), info.Size())
+	}
+	return nil
+}
+
+// addFile 附加单个文件，不满足条件时记录跳过原因
+func (e *expander) addFile(path string, size int64) {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		if e.seen[abs] {
+			return
+		}
+		e.seen[abs] = true
+	}
+
+	switch {
+	case len(e.result.Files) >= e.opts.MaxFiles:
+		e.skip(path, fmt.Sprintf("超过文件数量上限 %d", e.opts.MaxFiles))
+		return
+	case size > int64(e.opts.MaxFileBytes):
+		e.skip(path, fmt.Sprintf("文件大小 %d 字节超过上限 %d", size, e.opts.MaxFileBytes))
+		return
+	case e.result.TotalBytes+int(size) > e.opts.MaxTotalBytes:
+		e.skip(path, fmt.Sprintf("附加内容总大小超过上限 %d", e.opts.MaxTotalBytes))
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		e.skip(path, err.Error())
+		return
+	}
+	if IsBinary(data) {
+		e.skip(path, "二进制文件")
+		return
+	}
+
+	fence := "```"
+	for strings.Contains(string(data), fence) {
+		fence += "`"
+	}
+	content := strings.TrimRight(string(data), "\n")
+	fmt.Fprintf(&e.body, "\n--- 文件: %s ---\n%s%s\n%s\n%s\n", filepath.ToSlash(path), fence, langOf(path), content, fence)
+
+	e.result.Files = append(e.result.Files, path)
+	e.result.TotalBytes += len(data)
+}
+
+// addImage 附加直接引用的图片，图片大小同样计入附加内容的总大小
+func (e *expander) addImage(path, mime string, size int64) {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		if e.seen[abs] {
+			return
+		}
+		e.seen[abs] = true
+	}
+
+	switch {
+	case size > int64(e.opts.MaxImageBytes):
+		e.skip(path, fmt.Sprintf("图片大小 %d 字节超过上限 %d", size, e.opts.MaxImageBytes))
+		return
+	case e.result.TotalBytes+int(size) > e.opts.MaxTotalBytes:
+		e.skip(path, fmt.Sprintf("附加内容总大小超过上限 %d", e.opts.MaxTotalBytes))
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		e.skip(path, err.Error())
+		return
+	}
+	if detected := http.DetectContentType(data); !strings.HasPrefix(detected, "image/") {
+		e.skip(path, "文件内容不是图片")
+		return
+	}
+	e.result.Images = append(e.result.Images, Image{Path: path, MIME: mime, Data: data})
+	e.result.TotalBytes += len(data)
+}
+
+// skip 记录被跳过的文件
+func (e *expander) skip(path, reason string) {
+	e.result.Skipped = append(e.result.Skipped, fmt.Sprintf("%s（%s）", path, reason))
+}
+
+// IsBinary 判断内容是否为二进制数据：前 8000 字节中包含 NUL 或不是合法的 UTF-8 时视为二进制
+func IsBinary(data []byte) bool {
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+		// 截断位置可能落在多字节字符中间
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	return bytes.IndexByte(head, 0) >= 0 || !utf8.Valid(head)
+}
+
+// langOf 根据扩展名推断代码块语言
+func langOf(path string) string {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	switch ext {
+	case "yml":
+		return "yaml"
+	case "md":
+		return "markdown"
+	}
+	return ext
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
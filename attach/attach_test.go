@@ -0,0 +1,83 @@
+package attach
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.log\nbuild/\n!keep.log\n")
+	writeFile(t, filepath.Join(root, "src", "main.go"), "package main\n")
+	writeFile(t, filepath.Join(root, "src", "debug.log"), "noise")
+	writeFile(t, filepath.Join(root, "src", "keep.log"), "kept")
+	writeFile(t, filepath.Join(root, "src", "build", "out.txt"), "artifact")
+	writeFile(t, filepath.Join(root, "src", "vendor", "dep.go"), "package dep")
+	writeFile(t, filepath.Join(root, "src", "image.png"), "\x89PNG\x00\x00")
+	writeFile(t, filepath.Join(root, "src", "big.txt"), strings.Repeat("a", 64))
+
+	src := filepath.Join(root, "src")
+	result, err := Expand("review @"+src+"/ please", Options{MaxFileBytes: 32, Ignore: []string{"vendor/"}})
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	var names []string
+	for _, f := range result.Files {
+		names = append(names, filepath.Base(f))
+	}
+	if got := strings.Join(names, ","); got != "keep.log,main.go" {
+		t.Errorf("attached files = %s, want keep.log,main.go", got)
+	}
+	if len(result.Skipped) != 2 {
+		t.Errorf("skipped = %q, want big.txt and image.png", result.Skipped)
+	}
+	if !strings.Contains(result.Content, "--- 文件: "+filepath.ToSlash(filepath.Join(src, "main.go"))+" ---\n```go\npackage main\n```") {
+		t.Errorf("content missing main.go:\n%s", result.Content)
+	}
+}
+
+func TestExpandMissingPath(t *testing.T) {
+	result, err := Expand("mail me @someone", Options{})
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if result.Content != "mail me @someone" || len(result.Files) != 0 {
+		t.Errorf("Expand() = %+v", result)
+	}
+}
+
+func TestExpandImagesCountTowardsTotal(t *testing.T) {
+	root := t.TempDir()
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 56)
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		writeFile(t, filepath.Join(root, name), png)
+	}
+	a, b, c := filepath.Join(root, "a.png"), filepath.Join(root, "b.png"), filepath.Join(root, "c.png")
+
+	result, err := Expand("@"+a+" @"+a+" @"+b+" @"+c, Options{MaxTotalBytes: 150})
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(result.Images) != 2 || result.TotalBytes != 128 {
+		t.Errorf("images = %d, total = %d, want 2 images and 128 bytes", len(result.Images), result.TotalBytes)
+	}
+	if len(result.Skipped) != 1 || !strings.Contains(result.Skipped[0], "c.png") {
+		t.Errorf("skipped = %q, want c.png", result.Skipped)
+	}
+}
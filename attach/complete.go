@@ -0,0 +1,55 @@
+package attach
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxCandidates 补全候选项的最大数量
+const maxCandidates = 200
+
+// CompletePath 补全以 @ 开头的文件路径引用，供行编辑器的 Tab 补全使用。
+// param word 为光标前的单词。
+//
+// return 补全候选项，目录以 / 结尾；word 不以 @ 开头时返回 nil。
+func CompletePath(word string) []string {
+	if !strings.HasPrefix(word, "@") {
+		return nil
+	}
+
+	partial := word[1:]
+	dir, prefix := filepath.Split(partial)
+	readDir := expandHome(dir)
+	if readDir == "" {
+		readDir = "."
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// 未输入 . 时不补全隐藏文件
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		candidate := "@" + dir + name
+		if entry.IsDir() {
+			candidate += "/"
+		}
+		candidates = append(candidates, candidate)
+		if len(candidates) >= maxCandidates {
+			break
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
@@ -0,0 +1,179 @@
+package attach
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule 一条 .gitignore 规则
+type ignoreRule struct {
+	base    string         // 规则所在目录，规则路径相对于该目录
+	pattern *regexp.Regexp // 编译后的匹配表达式
+	negate  bool           // 是否为 ! 开头的反向规则
+	dirOnly bool           // 是否只匹配目录
+}
+
+// ignoreMatcher 按 .gitignore 语义判断路径是否被忽略
+type ignoreMatcher struct {
+	rules  []ignoreRule
+	loaded map[string]bool // 已加载过 .gitignore 的目录
+}
+
+// newIgnoreMatcher 创建忽略规则匹配器，加载 dir 及其上级目录直到 git 仓库根目录的 .gitignore。
+// param dir 为开始查找的目录。
+// param extra 为额外的忽略规则，相对于 dir 生效。
+//
+// return 创建完成的匹配器。
+func newIgnoreMatcher(dir string, extra []string) *ignoreMatcher {
+	m := &ignoreMatcher{loaded: make(map[string]bool)}
+
+	// 收集从仓库根目录到 dir 的目录链，父目录的规则先加载
+	var chain []string
+	for current := dir; ; {
+		chain = append([]string{current}, chain...)
+		if isDir(filepath.Join(current, ".git")) {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			// 不在 git 仓库中时只使用 dir 自身的 .gitignore
+			chain = []string{dir}
+			break
+		}
+		current = parent
+	}
+	for _, d := range chain {
+		m.load(d)
+	}
+
+	for _, line := range extra {
+		m.add(dir, line)
+	}
+	return m
+}
+
+// load 加载目录中的 .gitignore，每个目录只加载一次
+func (m *ignoreMatcher) load(dir string) {
+	if m.loaded[dir] {
+		return
+	}
+	m.loaded[dir] = true
+
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.add(dir, scanner.Text())
+	}
+}
+
+// add 解析并添加一条规则
+func (m *ignoreMatcher) add(base, line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// 规则中间包含 / 时相对于 base 匹配，否则匹配任意层级的文件名
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "(^|/)" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return
+	}
+	rule.pattern = re
+	m.rules = append(m.rules, rule)
+}
+
+// ignored 判断路径是否被忽略，后出现的规则优先
+func (m *ignoreMatcher) ignored(path string, dir bool) bool {
+	if filepath.Base(path) == ".git" {
+		return true
+	}
+
+	result := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if rule.pattern.MatchString(filepath.ToSlash(rel)) {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+// globToRegexp 将 gitignore 通配符转换为正则表达式
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**"):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// isDir 判断路径是否为已存在的目录
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,102 @@
+// Package catalog 缓存各服务提供的模型列表，避免每次查询都请求服务商。
+// 缓存按模型列表地址保存，不包含 API 密钥。
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sparrow-cli/client"
+	"sparrow-cli/file"
+	"time"
+)
+
+// FileName 缓存文件相对于家目录的路径
+const FileName = "cache/models.json"
+
+// DefaultTTL 缓存的默认有效期
+const DefaultTTL = 24 * time.Hour
+
+// Entry 一个服务的模型列表
+type Entry struct {
+	FetchedAt time.Time          `json:"fetched_at"` // 查询时间
+	Models    []client.ModelInfo `json:"models"`     // 服务提供的模型
+}
+
+// Cache 模型列表缓存
+type Cache struct {
+	path    string
+	Entries map[string]Entry `json:"entries"` // 按模型列表地址保存的模型列表
+}
+
+// DefaultPath 返回家目录下的缓存文件路径
+func DefaultPath(home string) string {
+	return filepath.Join(home, FileName)
+}
+
+// New 创建保存到指定文件的空缓存
+func New(path string) *Cache {
+	return &Cache{path: path, Entries: make(map[string]Entry)}
+}
+
+// Load 读取缓存文件，文件不存在时返回空缓存
+// param path 为缓存文件路径。
+//
+// return 缓存和可能的错误。
+func Load(path string) (*Cache, error) {
+	c := New(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取模型列表缓存失败: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("解析模型列表缓存失败 %s: %w", path, err)
+	}
+	if c.Entries == nil {
+		c.Entries = make(map[string]Entry)
+	}
+	return c, nil
+}
+
+// Get 返回未超过有效期的模型列表
+// param url 为模型列表地址。
+// param ttl 为有效期。
+//
+// return 模型列表，以及缓存是否存在且有效。
+func (c *Cache) Get(url string, ttl time.Duration) (Entry, bool) {
+	e, ok := c.Entries[url]
+	if !ok || time.Since(e.FetchedAt) > ttl {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Put 记录刚查询到的模型列表
+func (c *Cache) Put(url string, models []client.ModelInfo) {
+	c.Entries[url] = Entry{FetchedAt: time.Now(), Models: models}
+}
+
+// Save 写入缓存文件，先写入临时文件再替换以免损坏原文件
+func (c *Cache) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码模型列表缓存失败: %w", err)
+	}
+	if err := file.EnsureDir(filepath.Dir(c.path)); err != nil {
+		return err
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入模型列表缓存失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("写入模型列表缓存失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sparrow-cli/client"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	path := DefaultPath(t.TempDir())
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of missing file error = %v", err)
+	}
+	if _, ok := c.Get("https://api.openai.com/v1/models", DefaultTTL); ok {
+		t.Errorf("Get() on empty cache should miss")
+	}
+
+	models := []client.ModelInfo{{ID: "gpt-4o", OwnedBy: "system"}, {ID: "qwen-vl", ContextLength: 32768, Capabilities: []string{"vision"}}}
+	c.Put("https://api.openai.com/v1/models", models)
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	entry, ok := loaded.Get("https://api.openai.com/v1/models", DefaultTTL)
+	if !ok || !reflect.DeepEqual(entry.Models, models) {
+		t.Errorf("Get() = %+v, %v, want %+v", entry.Models, ok, models)
+	}
+	if _, ok := loaded.Get("https://api.openai.com/v1/models", -time.Second); ok {
+		t.Errorf("Get() should miss expired entries")
+	}
+
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(filepath.Join(filepath.Dir(path), "broken.json")); err == nil {
+		t.Errorf("Load() should reject a corrupted cache")
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"sparrow-cli/global"
+	"strings"
+)
+
+// DefaultAzureAPIVersion 未配置 api_version 时使用的 Azure OpenAI 接口版本
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// FinishContentFilter 回答因触发内容过滤而结束时的 finish_reason
+const FinishContentFilter = "content_filter"
+
+// ContentFilterError 提问被服务商的内容过滤拦截
+type ContentFilterError struct {
+	Categories []string // 触发过滤的类别，例如 hate(high)
+	Message    string   // 服务商返回的说明
+}
+
+func (e *ContentFilterError) Error() string {
+	if len(e.Categories) == 0 {
+		return "提问被内容过滤拦截: " + e.Message
+	}
+	return fmt.Sprintf("提问被内容过滤拦截（%s）: %s", strings.Join(e.Categories, ", "), e.Message)
+}
+
+// azureURL 拼接 Azure OpenAI 的接口地址。
+// url 为资源地址（例如 https://xxx.openai.azure.com）时拼接 /openai/deployments/{部署名称}/chat/completions，
+// 已包含 /openai/deployments/ 时原样使用；缺少 api-version 参数时补充。
+// 参数:
+//   - model: Azure 模型
+//
+// 返回:
+//   - string: 完整的接口地址
+//   - error: 地址无效时返回错误
+func azureURL(model *global.Model) (string, error) {
+	u, err := url.Parse(model.URL)
+	if err != nil {
+		return "", fmt.Errorf("接口地址无效 %s: %w", model.URL, err)
+	}
+	if !strings.Contains(u.Path, "/openai/deployments/") {
+		deployment := model.Deployment
+		if deployment == "" {
+			deployment = model.Name
+		}
+		u.Path = strings.TrimRight(u.Path, "/") + "/openai/deployments/" + deployment + "/chat/completions"
+	}
+
+	query := u.Query()
+	if query.Get("api-version") == "" {
+		version := model.APIVersion
+		if version == "" {
+			version = DefaultAzureAPIVersion
+		}
+		query.Set("api-version", version)
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+// azureError Azure OpenAI 的错误响应
+type azureError struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		InnerError struct {
+			Code                string                       `json:"code"`
+			ContentFilterResult map[string]contentFilterItem `json:"content_filter_result"`
+		} `json:"innererror"`
+	} `json:"error"`
+}
+
+// contentFilterItem 内容过滤中单个类别的结果
+type contentFilterItem struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// parseContentFilterError 识别内容过滤的错误响应，其他错误返回 nil
+func parseContentFilterError(body []byte) error {
+	var resp azureError
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	if resp.Error.Code != FinishContentFilter && resp.Error.InnerError.Code != "ResponsibleAIPolicyViolation" {
+		return nil
+	}
+
+	var categories []string
+	for name, item := range resp.Error.InnerError.ContentFilterResult {
+		if !item.Filtered {
+			continue
+		}
+		if item.Severity != "" && item.Severity != "safe" {
+			name += "(" + item.Severity + ")"
+		}
+		categories = append(categories, name)
+	}
+	sort.Strings(categories)
+	return &ContentFilterError{Categories: categories, Message: resp.Error.Message}
+}
+
+// Filtered 判断回答是否因触发内容过滤而被截断
+func (r *ResponseBody) Filtered() bool {
+	return len(r.Choices) > 0 && r.Choices[0].FinishReason == FinishContentFilter
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sparrow-cli/global"
+	"testing"
+)
+
+func TestAzureURL(t *testing.T) {
+	tests := []struct {
+		model global.Model
+		want  string
+	}{
+		{
+			global.Model{Name: "gpt-4o", URL: "https://demo.openai.azure.com/"},
+			"https://demo.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=" + DefaultAzureAPIVersion,
+		},
+		{
+			global.Model{Name: "gpt-4o", URL: "https://demo.openai.azure.com", Deployment: "chat", APIVersion: "2025-01-01-preview"},
+			"https://demo.openai.azure.com/openai/deployments/chat/chat/completions?api-version=2025-01-01-preview",
+		},
+		{
+			global.Model{Name: "gpt-4o", URL: "https://gw.example.com/openai/deployments/prod/chat/completions?api-version=2024-06-01"},
+			"https://gw.example.com/openai/deployments/prod/chat/completions?api-version=2024-06-01",
+		},
+	}
+	for _, tt := range tests {
+		got, err := azureURL(&tt.model)
+		if err != nil {
+			t.Fatalf("azureURL(%s) error = %v", tt.model.URL, err)
+		}
+		if got != tt.want {
+			t.Errorf("azureURL(%s) = %s, want %s", tt.model.URL, got, tt.want)
+		}
+	}
+}
+
+func TestAzureChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/chat/chat/completions" || r.Header.Get("api-key") != "azure-key" || r.Header.Get("Authorization") != "" {
+			http.Error(w, fmt.Sprintf("unexpected request %s %v", r.URL, r.Header), http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("api-version") == "blocked" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"code":"content_filter","message":"The response was filtered","innererror":{"code":"ResponsibleAIPolicyViolation",`+
+				`"content_filter_result":{"hate":{"filtered":true,"severity":"high"},"violence":{"filtered":false,"severity":"safe"},"jailbreak":{"filtered":true,"detected":true}}}}}`)
+			return
+		}
+		fmt.Fprint(w, "data: {\"id\":\"\",\"choices\":[],\"prompt_filter_results\":[{\"prompt_index\":0}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"部分回答\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"content_filter\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	model := &global.Model{Name: "gpt-4o", ApiKey: "azure-key", URL: server.URL, Provider: global.ProviderAzure, Deployment: "chat"}
+	messages := []Message{{Role: UserRole, Content: "你好"}}
+
+	resp, err := New(model).ChatStream(context.Background(), messages, 0.7, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	if resp.Choices[0].Message.Content != "部分回答" || !resp.Filtered() {
+		t.Errorf("ChatStream() = %+v, want filtered partial answer", resp.Choices[0])
+	}
+
+	model.APIVersion = "blocked"
+	_, err = New(model).ChatStream(context.Background(), messages, 0.7, nil)
+	var filterErr *ContentFilterError
+	if !errors.As(err, &filterErr) {
+		t.Fatalf("ChatStream() error = %v, want *ContentFilterError", err)
+	}
+	if len(filterErr.Categories) != 2 || filterErr.Categories[0] != "hate(high)" || filterErr.Categories[1] != "jailbreak" {
+		t.Errorf("Categories = %v", filterErr.Categories)
+	}
+}
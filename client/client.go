@@ -0,0 +1,360 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sparrow-cli/global"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Client 向单个模型发送请求的客户端，不读取全局的当前模型，可以同时向不同模型发送请求
+type Client struct {
+	model       *global.Model   // 发送请求的模型，提供名称、地址、密钥与服务商
+	httpClient  *http.Client    // 发送请求使用的 HTTP 客户端
+	timeout     time.Duration   // 等待响应头的超时时间，0 表示不限制
+	idleTimeout time.Duration   // 读取响应时两次收到数据的最长间隔，0 表示不限制
+	headers     http.Header     // 额外的请求头
+	format      *ResponseFormat // 要求模型输出的格式，为 nil 时不限制
+	err         error           // 按模型的传输设置创建 HTTP 客户端失败的原因，发送请求时返回
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithHTTPClient 使用指定的 HTTP 客户端发送请求，代替按模型传输设置创建的客户端
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+		c.err = nil
+	}
+}
+
+// WithTimeout 设置等待响应头的超时时间，覆盖模型的 first_byte_timeout，0 表示不限制
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithIdleTimeout 设置读取响应时两次收到数据的最长间隔，覆盖模型的 idle_timeout，0 表示不限制。
+// 只要持续有数据到达，长回答不会因此被截断
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.idleTimeout = timeout
+	}
+}
+
+// WithHeader 为每个请求添加额外的请求头，同名时覆盖默认请求头
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithResponseFormat 要求模型按指定格式输出，例如 JSON Schema，服务商不支持时接口会返回错误
+func WithResponseFormat(format *ResponseFormat) Option {
+	return func(c *Client) {
+		c.format = format
+	}
+}
+
+// StatusError 接口返回了非 200 的状态码
+type StatusError struct {
+	StatusCode int    // HTTP 状态码
+	Status     string // HTTP 状态，例如 429 Too Many Requests
+	Body       string // 响应体的开头部分，通常包含错误原因
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return e.Status
+	}
+	return e.Status + ": " + e.Body
+}
+
+// TimeoutError 等待响应头或读取响应体超时
+type TimeoutError struct {
+	msg string
+}
+
+func (e *TimeoutError) Error() string {
+	return e.msg
+}
+
+// Timeout 表示错误由超时引起，与 net.Error 一致
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// Retryable 判断请求在回答开始输出前的错误是否可以改用其他模型重试：超时、连接被拒绝或重置、
+// 连接提前关闭等网络错误，以及限流（429）与服务端错误（5xx）可以重试；请求本身有误（400）、认证失败（401、403）、
+// 提问被内容过滤拦截、证书校验失败、代理设置有误以及地址格式错误换一个模型通常同样失败，或应当让用户先处理，不重试
+func Retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var filterErr *ContentFilterError
+	if errors.As(err, &filterErr) || isConfigError(err) {
+		return false
+	}
+	var timeoutErr interface{ Timeout() bool }
+	if errors.As(err, &timeoutErr) && timeoutErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary
+	}
+	for _, target := range []error{io.EOF, io.ErrUnexpectedEOF, syscall.ECONNREFUSED, syscall.ECONNRESET,
+		syscall.ECONNABORTED, syscall.EPIPE, syscall.ENETUNREACH, syscall.EHOSTUNREACH} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isConfigError 判断错误是否由证书、TLS、代理或地址格式等配置问题引起
+func isConfigError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostnameErr      x509.HostnameError
+		verifyErr        *tls.CertificateVerificationError
+		recordErr        tls.RecordHeaderError
+		alertErr         tls.AlertError
+		opErr            *net.OpError
+		urlErr           *url.Error
+	)
+	switch {
+	case errors.As(err, &unknownAuthority), errors.As(err, &invalidCert), errors.As(err, &hostnameErr),
+		errors.As(err, &verifyErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return true
+	case errors.As(err, &opErr) && (opErr.Op == "proxyconnect" || opErr.Op == "socks connect"):
+		return true
+	case errors.As(err, &urlErr) && urlErr.Op == "parse":
+		return true
+	}
+	return false
+}
+
+const (
+	maxErrorBodyBytes   = 4096 // 读取错误响应体的最大字节数，内容过滤的错误信息较长
+	maxErrorDetailBytes = 512  // StatusError 中保留的响应体字节数
+)
+
+// New 创建向指定模型发送请求的客户端，按模型的传输设置配置超时、代理、TLS 与额外的请求头
+// 参数:
+//   - model: 发送请求的模型
+//   - opts: 客户端选项
+//
+// 返回:
+//   - *Client: 创建的客户端
+func New(model *global.Model, opts ...Option) *Client {
+	c := &Client{
+		model:       model,
+		timeout:     durationOr(model.Transport.FirstByteTimeout, defaultFirstByteTimeout),
+		idleTimeout: durationOr(model.Transport.IdleTimeout, defaultIdleTimeout),
+		headers:     make(http.Header),
+	}
+	for key, value := range model.Headers {
+		c.headers.Set(key, value)
+	}
+	c.httpClient, c.err = httpClientFor(model.Transport)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Model 返回客户端发送请求的模型
+func (c *Client) Model() *global.Model {
+	return c.model
+}
+
+// NewRequest 构建发送给模型的 HTTP 请求，请求体中的模型名称由客户端填写
+// 参数:
+//   - ctx: 请求的上下文
+//   - messages: 对话消息列表
+//   - temperature: 生成文本的随机性控制参数
+//   - stream: 是否启用流式响应
+//
+// 返回:
+//   - *http.Request: 构建完成的 HTTP 请求对象
+//   - error: 消息编码失败或模型地址无效时返回错误
+func (c *Client) NewRequest(ctx context.Context, messages []Message, temperature float64, stream bool) (*http.Request, error) {
+	// 按服务商的要求转换多模态内容
+	encoded, err := encodeMessages(c.model.Provider, messages)
+	if err != nil {
+		return nil, fmt.Errorf("构建请求消息失败: %w", err)
+	}
+
+	// 将请求体序列化为JSON
+	body := &RequestBody{
+		Model:       c.model.Name,
+		Messages:    encoded,
+		Temperature: temperature,
+		Stream:      stream,
+
+		ResponseFormat: c.format,
+	}
+	if stream {
+		body.StreamOptions = streamOptions(c.model)
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("JSON编码失败: %w", err)
+	}
+
+	// Azure 按部署名称拼接接口地址
+	endpoint := c.model.URL
+	if c.model.Provider == global.ProviderAzure {
+		if endpoint, err = azureURL(c.model); err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建 HTTP 请求
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
+	return req, nil
+}
+
+// setHeaders 设置认证与额外的请求头，Azure 使用 api-key 请求头而不是 Bearer 令牌
+func (c *Client) setHeaders(req *http.Request) {
+	switch {
+	case c.model.ApiKey == "":
+	case c.model.Provider == global.ProviderAzure:
+		req.Header.Set("api-key", c.model.ApiKey)
+	default:
+		req.Header.Set("Authorization", "Bearer "+c.model.ApiKey)
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+}
+
+// Chat 发送非流式请求并返回完整的回答
+// 参数:
+//   - ctx: 请求的上下文
+//   - messages: 对话消息列表
+//   - temperature: 生成文本的随机性控制参数
+//
+// 返回:
+//   - *ResponseBody: 解析后的响应数据结构
+//   - error: 请求失败、超时、接口返回非 200 状态码（*StatusError）、提问被内容过滤拦截（*ContentFilterError）
+//     或解析失败时返回错误。回答被内容过滤截断时不返回错误，可通过 ResponseBody.Filtered 判断
+func (c *Client) Chat(ctx context.Context, messages []Message, temperature float64) (*ResponseBody, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := newWatchdog(cancel)
+	defer w.stop()
+
+	resp, err := c.do(ctx, w, messages, temperature, false)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ParseResponse(resp)
+	if err != nil && w.expired.Load() {
+		return nil, c.idleError()
+	}
+	return responseBody, err
+}
+
+// ChatStream 发送流式请求，在每个数据块到达时调用回调函数
+// 参数:
+//   - ctx: 请求的上下文
+//   - messages: 对话消息列表
+//   - temperature: 生成文本的随机性控制参数
+//   - callback: 每个数据块的回调函数（参数: 增量内容, 是否结束）
+//
+// 返回:
+//   - *ResponseBody: 拼接后的完整响应数据结构
+//   - error: 请求失败、超时、接口返回非 200 状态码（*StatusError）、提问被内容过滤拦截（*ContentFilterError）
+//     或解析失败时返回错误。回调函数未被调用过时说明回答尚未开始输出，调用方可以安全地改用其他模型重试
+func (c *Client) ChatStream(ctx context.Context, messages []Message, temperature float64, callback func(content string, isFinished bool)) (*ResponseBody, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := newWatchdog(cancel)
+	defer w.stop()
+
+	resp, err := c.do(ctx, w, messages, temperature, true)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ParseStreamResponseWithCallback(resp, callback)
+	if err != nil && w.expired.Load() {
+		return nil, c.idleError()
+	}
+	return responseBody, err
+}
+
+// do 发送请求，等待响应头的时间受 timeout 限制，之后读取响应体的间隔受 idleTimeout 限制。
+// 接口返回非 200 状态码时关闭响应体并返回 *StatusError，提问触发内容过滤时返回 *ContentFilterError
+func (c *Client) do(ctx context.Context, w *watchdog, messages []Message, temperature float64, stream bool) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	req, err := c.NewRequest(ctx, messages, temperature, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	w.reset(c.timeout)
+	resp, err := c.httpClient.Do(req)
+	if w.expired.Load() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return nil, &TimeoutError{msg: fmt.Sprintf("等待模型 %s 响应超时（%s）", c.model.Name, c.timeout)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	w.reset(c.idleTimeout)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	resp.Body = &idleReader{ReadCloser: resp.Body, watchdog: w, timeout: c.idleTimeout}
+	return resp, nil
+}
+
+// statusError 读取非 200 响应的错误信息并关闭响应体，提问触发内容过滤时返回 *ContentFilterError，否则返回 *StatusError
+func statusError(resp *http.Response) error {
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if err := parseContentFilterError(detail); err != nil {
+		return err
+	}
+	if len(detail) > maxErrorDetailBytes {
+		detail = detail[:maxErrorDetailBytes]
+	}
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(strings.ToValidUTF8(string(detail), "")),
+	}
+}
+
+// idleError 读取响应时长时间没有收到数据
+func (c *Client) idleError() error {
+	return &TimeoutError{msg: fmt.Sprintf("模型 %s 超过 %s 没有返回新数据，已中断", c.model.Name, c.idleTimeout)}
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sparrow-cli/global"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestServer 模拟兼容 OpenAI 接口的服务，回答内容为请求的模型名称
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body RequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer key-"+body.Model {
+			http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+			return
+		}
+		if body.Model == "slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if !body.Stream {
+			fmt.Fprintf(w, `{"model":%q,"choices":[{"message":{"role":"assistant","content":"我是 %s"}}],"usage":{"total_tokens":3}}`, body.Model, body.Model)
+			return
+		}
+		for _, word := range []string{"我是 ", body.Model} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testModel(server *httptest.Server, name string) *global.Model {
+	return &global.Model{Name: name, ApiKey: "key-" + name, URL: server.URL, Provider: global.ProviderOpenAI}
+}
+
+func TestClientChat(t *testing.T) {
+	server := newTestServer(t)
+	messages := []Message{{Role: UserRole, Content: "你是谁"}}
+
+	resp, err := New(testModel(server, "gpt-4o")).Chat(context.Background(), messages, 0.7)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Choices[0].Message.Content != "我是 gpt-4o" || resp.Usage.TotalTokens != 3 {
+		t.Errorf("Chat() = %+v", resp)
+	}
+
+	model := testModel(server, "gpt-4o")
+	model.ApiKey = "wrong"
+	_, err = New(model).Chat(context.Background(), messages, 0.7)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized || !strings.Contains(statusErr.Body, "invalid api key") {
+		t.Errorf("Chat() with wrong key error = %v", err)
+	}
+}
+
+func TestClientChatStreamConcurrent(t *testing.T) {
+	server := newTestServer(t)
+	messages := []Message{{Role: UserRole, Content: "你是谁"}}
+
+	names := []string{"gpt-4o", "deepseek-chat", "qwen-plus"}
+	answers := make([]string, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var sb strings.Builder
+			resp, err := New(testModel(server, name)).ChatStream(context.Background(), messages, 0.7, func(content string, isFinished bool) {
+				sb.WriteString(content)
+			})
+			if err != nil {
+				t.Errorf("ChatStream(%s) error = %v", name, err)
+				return
+			}
+			if resp.Choices[0].Message.Content != sb.String() {
+				t.Errorf("ChatStream(%s) content = %q, streamed %q", name, resp.Choices[0].Message.Content, sb.String())
+			}
+			answers[i] = sb.String()
+		}()
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		if answers[i] != "我是 "+name {
+			t.Errorf("answer from %s = %q", name, answers[i])
+		}
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	server := newTestServer(t)
+	messages := []Message{{Role: UserRole, Content: "你是谁"}}
+
+	req, err := New(testModel(server, "gpt-4o"), WithHeader("X-Trace", "abc")).NewRequest(context.Background(), messages, 0.7, true)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	if req.Header.Get("X-Trace") != "abc" || req.Header.Get("Authorization") != "Bearer key-gpt-4o" {
+		t.Errorf("NewRequest() headers = %v", req.Header)
+	}
+
+	_, err = New(testModel(server, "slow"), WithTimeout(50*time.Millisecond)).ChatStream(context.Background(), messages, 0.7, nil)
+	if err == nil || !strings.Contains(err.Error(), "超时") {
+		t.Errorf("ChatStream() with timeout error = %v", err)
+	}
+}
+
+func TestClientTransport(t *testing.T) {
+	messages := []Message{{Role: UserRole, Content: "你是谁"}}
+
+	// 流式输出中途停顿超过 idle_timeout 时中断
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Api-Key") != "azure-key" {
+			http.Error(w, "missing api-key", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"我是\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer stalled.Close()
+
+	model := &global.Model{
+		Name:      "gpt-4o",
+		URL:       stalled.URL,
+		Headers:   map[string]string{"api-key": "azure-key"},
+		Transport: global.Transport{IdleTimeout: 50 * time.Millisecond},
+	}
+	started := false
+	_, err := New(model).ChatStream(context.Background(), messages, 0.7, func(content string, isFinished bool) {
+		started = true
+	})
+	if !started || err == nil || !strings.Contains(err.Error(), "没有返回新数据") {
+		t.Errorf("ChatStream() started = %v, error = %v", started, err)
+	}
+
+	// 自签名证书的服务需要配置 ca_file 或 insecure_skip_verify
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer secure.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: secure.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		transport global.Transport
+		ok        bool
+	}{
+		{"默认", global.Transport{}, false},
+		{"ca_file", global.Transport{CAFile: caFile}, true},
+		{"insecure_skip_verify", global.Transport{InsecureSkipVerify: true}, true},
+		{"无效的代理", global.Transport{Proxy: "ftp://proxy"}, false},
+	} {
+		model := &global.Model{Name: "gpt-4o", URL: secure.URL, Transport: tc.transport}
+		_, err := New(model).Chat(context.Background(), messages, 0.7)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: Chat() error = %v, want ok = %v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	messages := []Message{{Role: UserRole, Content: "你是谁"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/filter":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"code":"content_filter","message":"The response was filtered","innererror":{"code":"ResponsibleAIPolicyViolation",`+
+				`"content_filter_result":{"hate":{"filtered":true,"severity":"high"}}}}}`)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/reset":
+			// 返回响应头后断开连接，读取响应体时出错
+			w.Header().Set("Content-Length", "100")
+			fmt.Fprint(w, "data: ")
+		default:
+			var code int
+			fmt.Sscanf(r.URL.Path, "/%d", &code)
+			http.Error(w, `{"error":"failed"}`, code)
+		}
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	// 收到请求后不返回响应直接关闭连接
+	hangup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer hangup.Close()
+	secure := httptest.NewTLSServer(http.NotFoundHandler())
+	defer secure.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: secure.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(secure.Listener.Addr().String())
+
+	for _, tc := range []struct {
+		name      string
+		url       string
+		transport global.Transport
+		retryable bool
+	}{
+		{"请求有误", server.URL + "/400", global.Transport{}, false},
+		{"认证失败", server.URL + "/401", global.Transport{}, false},
+		{"没有权限", server.URL + "/403", global.Transport{}, false},
+		{"内容过滤", server.URL + "/filter", global.Transport{}, false},
+		{"限流", server.URL + "/429", global.Transport{}, true},
+		{"服务端错误", server.URL + "/500", global.Transport{}, true},
+		{"服务不可用", server.URL + "/503", global.Transport{}, true},
+		{"超时", server.URL + "/slow", global.Transport{}, true},
+		{"连接中断", server.URL + "/reset", global.Transport{}, true},
+		{"连接失败", closed.URL, global.Transport{}, true},
+		{"连接关闭", hangup.URL, global.Transport{}, true},
+		{"证书不受信任", secure.URL, global.Transport{}, false},
+		{"主机名不匹配", "https://localhost:" + port, global.Transport{CAFile: caFile}, false},
+		{"代理不可用", server.URL + "/500", global.Transport{Proxy: closed.URL}, false},
+		{"地址格式错误", "http://[::1", global.Transport{}, false},
+	} {
+		model := &global.Model{Name: "gpt-4o", URL: tc.url, Transport: tc.transport}
+		_, err := New(model, WithTimeout(50*time.Millisecond)).ChatStream(context.Background(), messages, 0.7, nil)
+		if err == nil {
+			t.Errorf("%s: ChatStream() error = nil", tc.name)
+			continue
+		}
+		if got := Retryable(err); got != tc.retryable {
+			t.Errorf("%s: Retryable(%v) = %v, want %v", tc.name, err, got, tc.retryable)
+		}
+	}
+
+	// 模型配置有误时不重试
+	if _, err := New(&global.Model{Name: "gpt-4o", URL: server.URL, Transport: global.Transport{Proxy: "ftp://proxy"}}).
+		ChatStream(context.Background(), messages, 0.7, nil); err == nil || Retryable(err) {
+		t.Errorf("ChatStream() with invalid proxy error = %v, want not retryable", err)
+	}
+}
+
+func TestStreamOptions(t *testing.T) {
+	for _, tc := range []struct {
+		model  global.Model
+		stream bool
+		want   bool
+	}{
+		{global.Model{Provider: global.ProviderOpenAI}, true, true},
+		{global.Model{Provider: global.ProviderOpenAI}, false, false},
+		{global.Model{Provider: global.ProviderDeepSeek}, true, true},
+		{global.Model{Provider: global.ProviderZhipu}, true, false},
+		{global.Model{Provider: global.ProviderAzure, Deployment: "chat"}, true, true},
+		{global.Model{Provider: global.ProviderAzure, Deployment: "chat", APIVersion: "2024-06-01"}, true, false},
+	} {
+		model := tc.model
+		model.Name, model.URL = "gpt-4o", "https://example.com/v1/chat/completions"
+		req, err := New(&model).NewRequest(context.Background(), []Message{{Role: UserRole, Content: "你好"}}, 0.7, tc.stream)
+		if err != nil {
+			t.Fatalf("NewRequest(%+v) error = %v", tc.model, err)
+		}
+		var body RequestBody
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if got := body.StreamOptions != nil && body.StreamOptions.IncludeUsage; got != tc.want {
+			t.Errorf("%s (api_version %q, stream %v): include_usage = %v, want %v", tc.model.Provider, tc.model.APIVersion, tc.stream, got, tc.want)
+		}
+	}
+}
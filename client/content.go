@@ -0,0 +1,192 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sparrow-cli/global"
+	"strings"
+)
+
+// ContentPartType 多模态内容片段的类型
+type ContentPartType string
+
+const (
+	PartText     ContentPartType = "text"      // 文本片段
+	PartImageURL ContentPartType = "image_url" // 图片片段，地址可以是 URL 或 data URL
+)
+
+// ContentPart 多模态消息中的单个内容片段
+type ContentPart struct {
+	Type     ContentPartType `json:"type"`                // 片段类型
+	Text     string          `json:"text,omitempty"`      // 文本内容
+	ImageURL *ImageURL       `json:"image_url,omitempty"` // 图片地址
+}
+
+// ImageURL 图片片段的地址信息
+type ImageURL struct {
+	URL    string `json:"url"`              // 图片 URL 或 data:<mime>;base64,<数据>
+	Detail string `json:"detail,omitempty"` // 图片解析精度（auto、low、high）
+}
+
+// TextPart 创建文本片段
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: PartText, Text: text}
+}
+
+// ImagePart 使用图片数据创建以 data URL 表示的图片片段
+// 参数:
+//   - mime: 图片的 MIME 类型，如 image/png
+//   - data: 图片的原始数据
+//
+// 返回:
+//   - ContentPart: 图片片段
+func ImagePart(mime string, data []byte) ContentPart {
+	return ContentPart{
+		Type: PartImageURL,
+		ImageURL: &ImageURL{
+			URL: "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
+
+// messageJSON 消息的序列化结构，content 可以是字符串或内容片段数组
+type messageJSON struct {
+	Role    Role            `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// MarshalJSON 纯文本消息的 content 序列化为字符串，包含内容片段时序列化为数组
+func (m Message) MarshalJSON() ([]byte, error) {
+	var content any = m.Content
+	if len(m.Parts) > 0 {
+		content = m.Parts
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(messageJSON{Role: m.Role, Content: raw})
+}
+
+// UnmarshalJSON 兼容字符串与内容片段数组两种 content 格式，数组中的文本片段会拼接到 Content
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw messageJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Role = raw.Role
+	m.Content = ""
+	m.Parts = nil
+
+	trimmed := strings.TrimSpace(string(raw.Content))
+	switch {
+	case trimmed == "" || trimmed == "null":
+		return nil
+	case strings.HasPrefix(trimmed, "["):
+		if err := json.Unmarshal(raw.Content, &m.Parts); err != nil {
+			return err
+		}
+		var sb strings.Builder
+		for _, part := range m.Parts {
+			if part.Type == PartText {
+				sb.WriteString(part.Text)
+			}
+		}
+		m.Content = sb.String()
+		return nil
+	default:
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+}
+
+// HasImages 判断消息是否包含图片
+func (m Message) HasImages() bool {
+	for _, part := range m.Parts {
+		if part.Type == PartImageURL {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsImages 判断服务商是否支持图片输入
+func SupportsImages(provider global.Provider) bool {
+	return provider != global.ProviderDeepSeek
+}
+
+// encodeMessages 按服务商的要求转换消息中的图片片段。
+// 服务商不支持图片时，之前轮次中的图片会被去掉并在文本中注明，以便对话切换到纯文本模型后继续进行；
+// 最后一条用户消息（本轮提问）包含图片时返回错误
+// 参数:
+//   - provider: 模型服务商
+//   - messages: 原始消息列表
+//
+// 返回:
+//   - []Message: 转换后的消息列表，不修改原始消息
+//   - error: 服务商不支持消息中的内容时返回错误
+func encodeMessages(provider global.Provider, messages []Message) ([]Message, error) {
+	current := -1
+	for i, msg := range messages {
+		if msg.Role == UserRole {
+			current = i
+		}
+	}
+
+	encoded := make([]Message, len(messages))
+	for i, msg := range messages {
+		encoded[i] = msg
+		if !msg.HasImages() {
+			continue
+		}
+		if !SupportsImages(provider) {
+			if i == current {
+				return nil, fmt.Errorf("服务商 %s 不支持图片输入", provider)
+			}
+			encoded[i] = withoutImages(msg)
+			continue
+		}
+
+		parts := make([]ContentPart, len(msg.Parts))
+		for j, part := range msg.Parts {
+			parts[j] = part
+			if part.Type != PartImageURL || part.ImageURL == nil {
+				continue
+			}
+			image := *part.ImageURL
+			switch provider {
+			case global.ProviderZhipu:
+				// 智谱只接受不带 data URL 前缀的 Base64 数据，且不支持 detail 参数
+				if idx := strings.Index(image.URL, ";base64,"); strings.HasPrefix(image.URL, "data:") && idx >= 0 {
+					image.URL = image.URL[idx+len(";base64,"):]
+				}
+				image.Detail = ""
+			case global.ProviderOllama, global.ProviderQwen:
+				// 兼容接口会忽略或拒绝 detail 参数
+				image.Detail = ""
+			}
+			parts[j].ImageURL = &image
+		}
+		encoded[i].Parts = parts
+	}
+	return encoded, nil
+}
+
+// withoutImages 将包含图片的消息转换为纯文本消息，并注明省略的图片数量
+func withoutImages(msg Message) Message {
+	images := 0
+	var sb strings.Builder
+	for _, part := range msg.Parts {
+		switch part.Type {
+		case PartText:
+			sb.WriteString(part.Text)
+		case PartImageURL:
+			images++
+		}
+	}
+	text := sb.String()
+	if text == "" {
+		text = msg.Content
+	}
+	return Message{Role: msg.Role, Content: strings.TrimSpace(fmt.Sprintf("%s\n\n[此处附加的 %d 张图片已省略：当前模型不支持图片输入]", text, images))}
+}
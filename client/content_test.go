@@ -0,0 +1,66 @@
+package client
+
+import (
+	"encoding/json"
+	"sparrow-cli/global"
+	"strings"
+	"testing"
+)
+
+func TestMessageMarshal(t *testing.T) {
+	text, _ := json.Marshal(Message{Role: UserRole, Content: "你好"})
+	if string(text) != `{"role":"user","content":"你好"}` {
+		t.Errorf("text message = %s", text)
+	}
+
+	multi, _ := json.Marshal(Message{
+		Role:  UserRole,
+		Parts: []ContentPart{TextPart("看图"), ImagePart("image/png", []byte("png"))},
+	})
+	want := `{"role":"user","content":[{"type":"text","text":"看图"},{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]}`
+	if string(multi) != want {
+		t.Errorf("multimodal message = %s, want %s", multi, want)
+	}
+
+	var decoded Message
+	if err := json.Unmarshal(multi, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Content != "看图" || !decoded.HasImages() {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
+
+func TestEncodeMessages(t *testing.T) {
+	image := ImagePart("image/png", []byte("png"))
+	image.ImageURL.Detail = "high"
+	messages := []Message{{Role: UserRole, Parts: []ContentPart{TextPart("看图"), image}}}
+
+	zhipu, err := encodeMessages(global.ProviderZhipu, messages)
+	if err != nil {
+		t.Fatalf("encodeMessages(zhipu) error = %v", err)
+	}
+	if got := zhipu[0].Parts[1].ImageURL; got.URL != "cG5n" || got.Detail != "" {
+		t.Errorf("zhipu image = %+v", got)
+	}
+	if messages[0].Parts[1].ImageURL.URL == "cG5n" {
+		t.Errorf("encodeMessages modified the original message")
+	}
+
+	if _, err := encodeMessages(global.ProviderDeepSeek, messages); err == nil {
+		t.Errorf("encodeMessages(deepseek) should reject images")
+	}
+
+	// 之前轮次中的图片在纯文本模型中省略，本轮提问可以继续发送
+	history := append(messages, Message{Role: AssistantRole, Content: "是一只猫"}, Message{Role: UserRole, Content: "它是什么颜色"})
+	deepseek, err := encodeMessages(global.ProviderDeepSeek, history)
+	if err != nil {
+		t.Fatalf("encodeMessages(deepseek) with earlier images error = %v", err)
+	}
+	if deepseek[0].HasImages() || !strings.HasPrefix(deepseek[0].Content, "看图\n\n[此处附加的 1 张图片已省略") {
+		t.Errorf("earlier image message = %+v", deepseek[0])
+	}
+	if !history[0].HasImages() {
+		t.Errorf("encodeMessages modified the original history")
+	}
+}
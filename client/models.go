@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sparrow-cli/global"
+	"strings"
+)
+
+// ModelInfo 服务商模型列表中的一个模型，上下文长度与能力只在服务商提供时才有值
+type ModelInfo struct {
+	ID            string   `json:"id"`                       // 模型名称，即请求体中的 model
+	OwnedBy       string   `json:"owned_by,omitempty"`       // 模型所属的组织
+	Created       int64    `json:"created,omitempty"`        // 模型发布的时间戳（Unix 时间戳）
+	ContextLength int      `json:"context_length,omitempty"` // 上下文长度（Token 数）
+	Capabilities  []string `json:"capabilities,omitempty"`   // 模型能力，例如 chat_completion、vision
+}
+
+// contextLengthKeys 各服务商表示上下文长度的字段，按顺序取第一个有值的字段
+var contextLengthKeys = []string{"context_length", "context_window", "max_context_length", "max_model_len", "max_input_tokens"}
+
+// ModelsURL 返回模型所在服务的模型列表地址。
+// 对话地址以 /chat/completions 结尾时替换为 /models，否则在地址后追加 /models；
+// Azure 使用资源地址下的 /openai/models。
+// 参数:
+//   - model: 配置的模型
+//
+// 返回:
+//   - string: 模型列表地址
+//   - error: 地址无效时返回错误
+func ModelsURL(model *global.Model) (string, error) {
+	u, err := url.Parse(model.URL)
+	if err != nil {
+		return "", fmt.Errorf("接口地址无效 %s: %w", model.URL, err)
+	}
+	path := strings.TrimRight(u.Path, "/")
+	u.RawQuery = ""
+	if model.Provider == global.ProviderAzure {
+		if i := strings.Index(path, "/openai/"); i >= 0 {
+			path = path[:i]
+		}
+		u.Path = path + "/openai/models"
+		version := model.APIVersion
+		if version == "" {
+			version = DefaultAzureAPIVersion
+		}
+		u.RawQuery = url.Values{"api-version": {version}}.Encode()
+		return u.String(), nil
+	}
+	u.Path = strings.TrimSuffix(path, "/chat/completions") + "/models"
+	return u.String(), nil
+}
+
+// ListModels 查询模型所在服务提供的模型列表，按名称排序
+// 参数:
+//   - ctx: 请求的上下文
+//
+// 返回:
+//   - []ModelInfo: 服务提供的模型
+//   - error: 请求失败、超时、接口返回非 200 状态码（*StatusError）或解析失败时返回错误
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	endpoint, err := ModelsURL(c.model)
+	if err != nil {
+		return nil, err
+	}
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data []map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败: %w", err)
+	}
+	models := make([]ModelInfo, 0, len(body.Data))
+	for _, item := range body.Data {
+		if info := parseModelInfo(item); info.ID != "" {
+			models = append(models, info)
+		}
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// parseModelInfo 解析模型列表中的一项，兼容各服务商不同的字段
+func parseModelInfo(item map[string]json.RawMessage) ModelInfo {
+	var info ModelInfo
+	json.Unmarshal(item["id"], &info.ID)
+	json.Unmarshal(item["owned_by"], &info.OwnedBy)
+	json.Unmarshal(item["created"], &info.Created)
+	if info.Created == 0 {
+		// Azure 使用 created_at
+		json.Unmarshal(item["created_at"], &info.Created)
+	}
+	for _, key := range contextLengthKeys {
+		if json.Unmarshal(item[key], &info.ContextLength) == nil && info.ContextLength > 0 {
+			break
+		}
+	}
+	info.Capabilities = parseCapabilities(item)
+	return info
+}
+
+// parseCapabilities 解析模型能力。capabilities 可能是名称列表，也可能是名称到布尔值的映射（Azure）；
+// 输入模态包含图片时视为支持 vision（OpenRouter）
+func parseCapabilities(item map[string]json.RawMessage) []string {
+	var capabilities []string
+	if raw, ok := item["capabilities"]; ok {
+		var flags map[string]bool
+		if json.Unmarshal(raw, &capabilities) != nil && json.Unmarshal(raw, &flags) == nil {
+			for name, enabled := range flags {
+				if enabled {
+					capabilities = append(capabilities, name)
+				}
+			}
+		}
+	}
+
+	var architecture struct {
+		InputModalities []string `json:"input_modalities"`
+	}
+	if json.Unmarshal(item["architecture"], &architecture) == nil {
+		for _, modality := range architecture.InputModalities {
+			if modality == "image" {
+				capabilities = append(capabilities, "vision")
+				break
+			}
+		}
+	}
+	sort.Strings(capabilities)
+	return capabilities
+}
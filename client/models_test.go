@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sparrow-cli/global"
+	"testing"
+)
+
+func TestModelsURL(t *testing.T) {
+	tests := []struct {
+		model global.Model
+		want  string
+	}{
+		{global.Model{URL: "https://api.openai.com/v1/chat/completions"}, "https://api.openai.com/v1/models"},
+		{global.Model{URL: "https://api.deepseek.com/chat/completions"}, "https://api.deepseek.com/models"},
+		{global.Model{URL: "http://localhost:11434/v1/", Provider: global.ProviderOllama}, "http://localhost:11434/v1/models"},
+		{global.Model{URL: "https://demo.openai.azure.com", Provider: global.ProviderAzure}, "https://demo.openai.azure.com/openai/models?api-version=" + DefaultAzureAPIVersion},
+		{
+			global.Model{URL: "https://gw.example.com/openai/deployments/prod/chat/completions?api-version=2024-06-01", Provider: global.ProviderAzure, APIVersion: "2025-01-01-preview"},
+			"https://gw.example.com/openai/models?api-version=2025-01-01-preview",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ModelsURL(&tt.model)
+		if err != nil {
+			t.Fatalf("ModelsURL(%s) error = %v", tt.model.URL, err)
+		}
+		if got != tt.want {
+			t.Errorf("ModelsURL(%s) = %s, want %s", tt.model.URL, got, tt.want)
+		}
+	}
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/models" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, `{"error":{"message":"Incorrect API key provided"}}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[
+			{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"},
+			{"id":"anthropic/claude-sonnet","context_length":200000,"architecture":{"input_modalities":["text","image"]}},
+			{"id":"llama-3.3-70b","context_window":131072,"owned_by":"Meta"},
+			{"id":"gpt-4o-azure","created_at":1715367049,"capabilities":{"chat_completion":true,"embeddings":false,"fine_tune":true}},
+			{"id":"qwen-vl","max_model_len":32768,"capabilities":["vision","tools"]},
+			{"object":"model"}
+		]}`)
+	}))
+	defer server.Close()
+
+	model := &global.Model{Name: "gpt-4o", ApiKey: "sk-test", URL: server.URL + "/v1/chat/completions"}
+	models, err := New(model).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	want := []ModelInfo{
+		{ID: "anthropic/claude-sonnet", ContextLength: 200000, Capabilities: []string{"vision"}},
+		{ID: "gpt-4o", OwnedBy: "system", Created: 1715367049},
+		{ID: "gpt-4o-azure", Created: 1715367049, Capabilities: []string{"chat_completion", "fine_tune"}},
+		{ID: "llama-3.3-70b", OwnedBy: "Meta", ContextLength: 131072},
+		{ID: "qwen-vl", ContextLength: 32768, Capabilities: []string{"tools", "vision"}},
+	}
+	if !reflect.DeepEqual(models, want) {
+		t.Errorf("ListModels() =\n%+v\nwant\n%+v", models, want)
+	}
+
+	model.ApiKey = "wrong"
+	_, err = New(model).ListModels(context.Background())
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("ListModels() error = %v, want 401 StatusError", err)
+	}
+}
@@ -1,8 +1,7 @@
 package client
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"net/http"
 	"sparrow-cli/global"
 	"sparrow-cli/logger"
@@ -23,12 +22,43 @@ type RequestBody struct {
 	Messages    []Message `json:"messages"`    // 对话消息列表
 	Temperature float64   `json:"temperature"` // 生成文本的随机性控制参数（0.0-2.0）
 	Stream      bool      `json:"stream"`      // 是否启用流式响应
+
+	StreamOptions  *StreamOptions  `json:"stream_options,omitempty"`  // 流式响应的选项，为 nil 时不发送
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"` // 要求模型输出 JSON，为 nil 时不限制
+}
+
+// StreamOptions 流式响应的选项
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // 在最后一个数据块中返回 Token 使用情况
+}
+
+// azureStreamUsageVersion 支持 stream_options 的最低 Azure 接口版本，更早的版本会拒绝未知参数
+const azureStreamUsageVersion = "2024-09-01"
+
+// streamOptions 返回流式请求的 stream_options。OpenAI 等服务商默认不在流式响应中返回 Token 使用情况，
+// 需要通过 include_usage 要求；智谱默认返回，不发送该参数
+func streamOptions(model *global.Model) *StreamOptions {
+	switch global.ParseProvider(string(model.Provider)) {
+	case global.ProviderOpenAI, global.ProviderDeepSeek, global.ProviderQwen, global.ProviderOllama:
+		return &StreamOptions{IncludeUsage: true}
+	case global.ProviderAzure:
+		version := model.APIVersion
+		if version == "" {
+			version = DefaultAzureAPIVersion
+		}
+		if version >= azureStreamUsageVersion {
+			return &StreamOptions{IncludeUsage: true}
+		}
+	}
+	return nil
 }
 
 // Message 单条对话消息结构
 type Message struct {
-	Role    Role   `json:"role"`    // 消息发送者角色
-	Content string `json:"content"` // 消息内容文本
+	Role    Role          `json:"role"`    // 消息发送者角色
+	Content string        `json:"content"` // 消息内容文本
+	Parts   []ContentPart `json:"-"`       // 多模态内容片段，非空时代替 Content 发送
+	Model   string        `json:"-"`       // 回答该消息的模型，仅记录在对话历史中，不会发送给接口
 }
 
 // BuildRequest 构建 AI API 的 HTTP 请求（向后兼容，默认非流式）
@@ -39,20 +69,24 @@ type Message struct {
 // 返回:
 //   - *http.Request: 构建完成的 HTTP 请求对象
 //
-// 已弃用: 推荐使用 BuildNonStreamRequest 或 BuildStreamRequest
+// 已弃用: 推荐使用 Client.Chat 或 Client.ChatStream
 func BuildRequest(messages []Message, temperature float64) *http.Request {
-	// 构建请求体数据（非流式）
-	reqBody := &RequestBody{
-		Model:       global.CurrentModel.Name, // 从全局配置获取模型名称
-		Messages:    messages,                 // 传入的对话消息
-		Temperature: temperature,              // 设置温度参数
-		Stream:      false,                    // 设置为非流式响应
-	}
+	return BuildModelRequest(currentModel(), messages, temperature)
+}
 
-	return buildHTTPRequest(reqBody)
+// BuildModelRequest 使用指定模型构建非流式 AI API 的 HTTP 请求，是 Client.NewRequest 的简单包装
+// 参数:
+//   - model: 发送请求的模型
+//   - messages: 对话消息列表
+//   - temperature: 生成文本的随机性控制参数
+//
+// 返回:
+//   - *http.Request: 构建完成的 HTTP 请求对象
+func BuildModelRequest(model *global.Model, messages []Message, temperature float64) *http.Request {
+	return buildHTTPRequest(model, messages, temperature, false)
 }
 
-// BuildStreamRequest 构建流式 AI API 的 HTTP 请求
+// BuildStreamRequest 使用当前模型构建流式 AI API 的 HTTP 请求
 // 参数:
 //   - messages: 对话消息列表
 //   - temperature: 生成文本的随机性控制参数
@@ -60,39 +94,34 @@ func BuildRequest(messages []Message, temperature float64) *http.Request {
 // 返回:
 //   - *http.Request: 构建完成的流式 HTTP 请求对象
 func BuildStreamRequest(messages []Message, temperature float64) *http.Request {
-	// 构建请求体数据（流式）
-	reqBody := &RequestBody{
-		Model:       global.CurrentModel.Name, // 从全局配置获取模型名称
-		Messages:    messages,                 // 传入的对话消息
-		Temperature: temperature,              // 设置温度参数
-		Stream:      true,                     // 设置为流式响应
-	}
-
-	return buildHTTPRequest(reqBody)
+	return BuildModelStreamRequest(currentModel(), messages, temperature)
 }
 
-// buildHTTPRequest 构建 HTTP 请求的内部方法
+// BuildModelStreamRequest 使用指定模型构建流式 AI API 的 HTTP 请求，是 Client.NewRequest 的简单包装
 // 参数:
-//   - reqBody: 请求体数据结构
+//   - model: 发送请求的模型
+//   - messages: 对话消息列表
+//   - temperature: 生成文本的随机性控制参数
 //
 // 返回:
-//   - *http.Request: 构建完成的 HTTP 请求对象
-func buildHTTPRequest(reqBody *RequestBody) *http.Request {
-	// 将请求体序列化为JSON
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		logger.Fatal("JSON编码失败: %v", err)
-	}
+//   - *http.Request: 构建完成的流式 HTTP 请求对象
+func BuildModelStreamRequest(model *global.Model, messages []Message, temperature float64) *http.Request {
+	return buildHTTPRequest(model, messages, temperature, true)
+}
 
-	// 创建 HTTP 请求
-	req, err := http.NewRequest("POST", global.CurrentModel.URL, bytes.NewBuffer(jsonData))
+// buildHTTPRequest 构建 HTTP 请求的内部方法，失败时直接退出
+func buildHTTPRequest(model *global.Model, messages []Message, temperature float64, stream bool) *http.Request {
+	req, err := New(model).NewRequest(context.Background(), messages, temperature, stream)
 	if err != nil {
-		logger.Fatal("创建请求失败: %v", err)
+		logger.Fatal("%v", err)
 	}
-
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+global.CurrentModel.ApiKey)
-
 	return req
 }
+
+// currentModel 返回当前模型，尚未配置模型时直接退出，避免空指针
+func currentModel() *global.Model {
+	if global.CurrentModel == nil {
+		logger.Fatal("尚未配置任何模型，请运行 sparrow-cli setup")
+	}
+	return global.CurrentModel
+}
@@ -41,6 +41,7 @@ type StreamChunk struct {
 	Created int64               `json:"created"` // 响应创建的时间戳（Unix 时间戳）
 	Model   string              `json:"model"`   // 使用的AI模型名称
 	Choices []StreamChunkChoice `json:"choices"` // 流式响应选择列表
+	Usage   *Usage              `json:"usage"`   // Token使用情况，OpenAI、DeepSeek 等服务商放在最后一个块的顶层
 }
 
 // StreamChunkChoice 流式响应中的单个选择项
@@ -144,6 +145,11 @@ func ParseStreamResponse(resp *http.Response) (*ResponseBody, error) {
 				result.Model = chunk.Model
 			}
 
+			// 获取顶层的 Token 使用情况，该块的选择项可能为空
+			if chunk.Usage != nil {
+				result.Usage = *chunk.Usage
+			}
+
 			// 处理选择项
 			if len(chunk.Choices) > 0 {
 				choice := chunk.Choices[0]
@@ -168,7 +174,7 @@ func ParseStreamResponse(resp *http.Response) (*ResponseBody, error) {
 
 	// 检查扫描错误
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("读取流式响应失败: %v", err)
+		return nil, fmt.Errorf("读取流式响应失败: %w", err)
 	}
 
 	// 设置最终内容
@@ -239,6 +245,11 @@ func ParseStreamResponseWithCallback(resp *http.Response, callback func(content
 				result.Model = chunk.Model
 			}
 
+			// 获取顶层的 Token 使用情况，该块的选择项可能为空
+			if chunk.Usage != nil {
+				result.Usage = *chunk.Usage
+			}
+
 			// 处理选择项
 			if len(chunk.Choices) > 0 {
 				choice := chunk.Choices[0]
@@ -266,7 +277,7 @@ func ParseStreamResponseWithCallback(resp *http.Response, callback func(content
 
 	// 检查扫描错误
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("读取流式响应失败: %v", err)
+		return nil, fmt.Errorf("读取流式响应失败: %w", err)
 	}
 
 	// 设置最终内容
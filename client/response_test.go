@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sparrow-cli/fixture"
+	"sparrow-cli/global"
+	"strings"
+	"testing"
+)
+
+// replayClient 创建从录制文件回放响应的客户端
+func replayClient(t *testing.T, name string, model *global.Model) (*Client, *fixture.Replayer) {
+	t.Helper()
+	replayer, err := fixture.LoadReplayer(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(model, WithHTTPClient(&http.Client{Transport: replayer})), replayer
+}
+
+// checkRecordedRequest 检查录制文件中的请求体与客户端实际发送的一致，以免示例数据与请求脱节
+func checkRecordedRequest(t *testing.T, name string, c *Client, messages []Message) {
+	t.Helper()
+	cassette, err := fixture.Load(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := c.NewRequest(context.Background(), messages, 0.7, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded := cassette.Interactions[0].Request.Body; recorded != string(body) {
+		t.Errorf("%s: recorded request body = %s, client sends %s", name, recorded, body)
+	}
+}
+
+func TestParseProviderStreams(t *testing.T) {
+	tests := []struct {
+		fixture      string
+		model        global.Model
+		content      string
+		finishReason string
+		usage        Usage
+	}{
+		{
+			fixture:      "openai_stream.yaml",
+			model:        global.Model{Name: "gpt-4o", ApiKey: "sk-test", URL: "https://api.openai.com/v1/chat/completions"},
+			content:      "Go 是一门简洁高效的编程语言。",
+			finishReason: "stop",
+			usage:        Usage{PromptTokens: 15, CompletionTokens: 12, TotalTokens: 27},
+		},
+		{
+			fixture:      "deepseek_stream.yaml",
+			model:        global.Model{Name: "deepseek-chat", ApiKey: "sk-test", URL: "https://api.deepseek.com/chat/completions", Provider: global.ProviderDeepSeek},
+			content:      "Go 是 Google 开发的静态类型语言。",
+			finishReason: "stop",
+			usage:        Usage{PromptTokens: 12, CompletionTokens: 10, TotalTokens: 22},
+		},
+		{
+			fixture:      "azure_stream.yaml",
+			model:        global.Model{Name: "gpt-4o", ApiKey: "azure-key", URL: "https://demo.openai.azure.com", Provider: global.ProviderAzure, Deployment: "chat"},
+			content:      "Go 是一门开源编程语言。",
+			finishReason: "stop",
+			usage:        Usage{PromptTokens: 14, CompletionTokens: 8, TotalTokens: 22},
+		},
+		{
+			fixture:      "qwen_stream.yaml",
+			model:        global.Model{Name: "qwen-plus", ApiKey: "sk-test", URL: "https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions", Provider: global.ProviderQwen},
+			content:      "Go 是一门并发友好的语言。",
+			finishReason: "stop",
+			usage:        Usage{PromptTokens: 14, CompletionTokens: 9, TotalTokens: 23},
+		},
+		{
+			fixture:      "zhipu_stream.yaml",
+			model:        global.Model{Name: "glm-4-flash", ApiKey: "key.secret", URL: "https://open.bigmodel.cn/api/paas/v4/chat/completions", Provider: global.ProviderZhipu},
+			content:      "Go语言简单可靠。",
+			finishReason: "stop",
+			usage:        Usage{PromptTokens: 10, CompletionTokens: 6, TotalTokens: 16},
+		},
+		{
+			fixture:      "ollama_stream.yaml",
+			model:        global.Model{Name: "llama3", URL: "http://localhost:11434/v1/chat/completions", Provider: global.ProviderOllama},
+			content:      "Go is a compiled language.",
+			finishReason: "stop",
+			usage:        Usage{PromptTokens: 16, CompletionTokens: 6, TotalTokens: 22},
+		},
+	}
+
+	messages := []Message{{Role: UserRole, Content: "用一句话介绍 Go"}}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			c, replayer := replayClient(t, tt.fixture, &tt.model)
+			checkRecordedRequest(t, tt.fixture, c, messages)
+
+			var streamed strings.Builder
+			resp, err := c.ChatStream(context.Background(), messages, 0.7, func(content string, isFinished bool) {
+				streamed.WriteString(content)
+			})
+			if err != nil {
+				t.Fatalf("ChatStream() error = %v", err)
+			}
+			if got := resp.Choices[0].Message.Content; got != tt.content || streamed.String() != tt.content {
+				t.Errorf("content = %q, streamed %q, want %q", got, streamed.String(), tt.content)
+			}
+			if resp.Choices[0].FinishReason != tt.finishReason {
+				t.Errorf("finish reason = %q, want %q", resp.Choices[0].FinishReason, tt.finishReason)
+			}
+			if resp.Usage != tt.usage {
+				t.Errorf("usage = %+v, want %+v", resp.Usage, tt.usage)
+			}
+			if replayer.Remaining() != 0 {
+				t.Errorf("%d recorded interactions were not replayed", replayer.Remaining())
+			}
+		})
+	}
+}
+
+func TestParseProviderError(t *testing.T) {
+	model := &global.Model{Name: "gpt-4o", ApiKey: "sk-test", URL: "https://api.openai.com/v1/chat/completions"}
+	c, _ := replayClient(t, "openai_rate_limit.yaml", model)
+	checkRecordedRequest(t, "openai_rate_limit.yaml", c, []Message{{Role: UserRole, Content: "用一句话介绍 Go"}})
+
+	_, err := c.ChatStream(context.Background(), []Message{{Role: UserRole, Content: "用一句话介绍 Go"}}, 0.7, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || !strings.Contains(statusErr.Body, "rate_limit_exceeded") {
+		t.Errorf("ChatStream() error = %v, want 429 StatusError", err)
+	}
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sparrow-cli/global"
+	"sparrow-cli/schema"
+	"strings"
+)
+
+// JSONMode 要求模型输出 JSON 的方式
+type JSONMode string
+
+const (
+	JSONModeSchema JSONMode = "json_schema" // 通过 response_format 传递 JSON Schema，由服务商约束输出
+	JSONModeObject JSONMode = "json_object" // 通过 response_format 要求输出 JSON 对象，Schema 写在提示词中
+	JSONModePrompt JSONMode = "prompt"      // 只在提示词中说明 Schema
+)
+
+// ResponseFormat 请求体中的 response_format
+type ResponseFormat struct {
+	Type       JSONMode          `json:"type"`                  // json_schema 或 json_object
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"` // type 为 json_schema 时的 Schema
+}
+
+// JSONSchemaFormat response_format 中的 JSON Schema
+type JSONSchemaFormat struct {
+	Name   string          `json:"name"`   // Schema 名称
+	Schema json.RawMessage `json:"schema"` // Schema 内容
+	Strict bool            `json:"strict"` // 是否严格遵守，严格模式对 Schema 的写法有额外要求，因此不开启，由本地校验兜底
+}
+
+// SupportsJSONSchema 判断服务商是否支持通过 response_format 传递 JSON Schema，其余服务商只支持 json_object
+func SupportsJSONSchema(provider global.Provider) bool {
+	switch global.ParseProvider(string(provider)) {
+	case global.ProviderOpenAI, global.ProviderAzure, global.ProviderOllama:
+		return true
+	default:
+		return false
+	}
+}
+
+// JSONRequest 结构化输出请求
+type JSONRequest struct {
+	Messages    []Message                    // 对话消息列表
+	Temperature float64                      // 生成文本的随机性控制参数
+	Schema      *schema.Schema               // 回答需要符合的 JSON Schema
+	Retries     int                          // 回答不符合 Schema 时重新提问的最大次数
+	OnRetry     func(attempt int, err error) // 每次重新提问前调用，参数为已提问的次数与上一次回答的问题，可为 nil
+}
+
+// JSONResult 通过校验的结构化输出
+type JSONResult struct {
+	JSON     json.RawMessage // 通过校验的 JSON，保留模型输出的原文
+	Mode     JSONMode        // 最终使用的方式
+	Attempts int             // 提问次数
+	Usage    Usage           // 所有提问累计的 Token 使用情况
+}
+
+// SchemaError 重新提问后回答仍不符合 JSON Schema
+type SchemaError struct {
+	Attempts int    // 提问次数
+	Reply    string // 最后一次的回答
+	Err      error  // 最后一次回答的问题，通常是 *schema.ValidationError
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("提问 %d 次后回答仍不符合 JSON Schema: %v", e.Attempts, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// ChatJSON 要求模型输出符合 JSON Schema 的 JSON，在本地校验回答，不符合时附上问题重新提问。
+// 服务商支持时通过 response_format 传递 Schema，否则要求输出 JSON 对象并在提示词中说明 Schema；
+// 接口以 400 拒绝 response_format 时自动改用更宽松的方式，不计入重新提问次数。
+// 参数:
+//   - ctx: 请求的上下文
+//   - req: 结构化输出请求
+//
+// 返回:
+//   - *JSONResult: 通过校验的 JSON
+//   - error: 请求失败时返回请求的错误，多次提问后仍不符合 Schema 时返回 *SchemaError
+func (c *Client) ChatJSON(ctx context.Context, req JSONRequest) (*JSONResult, error) {
+	mode := JSONModePrompt
+	switch {
+	case SupportsJSONSchema(c.model.Provider):
+		mode = JSONModeSchema
+	case req.Schema.RootType() == "object":
+		mode = JSONModeObject
+	}
+
+	result := &JSONResult{}
+	var conversation []Message
+	for {
+		if conversation == nil {
+			conversation = jsonMessages(req.Messages, req.Schema, mode)
+		}
+		format := responseFormat(req.Schema, mode)
+		requestClient := *c
+		requestClient.format = format
+
+		resp, err := requestClient.Chat(ctx, conversation, req.Temperature)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && mode != JSONModePrompt &&
+			(statusErr.StatusCode == http.StatusBadRequest || statusErr.StatusCode == http.StatusUnprocessableEntity) {
+			// 服务商不支持该 response_format，改用更宽松的方式重新开始
+			mode = fallbackMode(mode, req.Schema)
+			conversation = nil
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Attempts++
+		result.Mode = mode
+		result.Usage.PromptTokens += resp.Usage.PromptTokens
+		result.Usage.CompletionTokens += resp.Usage.CompletionTokens
+		result.Usage.TotalTokens += resp.Usage.TotalTokens
+
+		reply := ""
+		if len(resp.Choices) > 0 {
+			reply = resp.Choices[0].Message.Content
+		}
+		data := extractJSON(reply)
+		if _, err = req.Schema.Validate([]byte(data)); err == nil {
+			result.JSON = json.RawMessage(data)
+			return result, nil
+		}
+		if result.Attempts > req.Retries {
+			return nil, &SchemaError{Attempts: result.Attempts, Reply: reply, Err: err}
+		}
+		if req.OnRetry != nil {
+			req.OnRetry(result.Attempts, err)
+		}
+		conversation = append(conversation,
+			Message{Role: AssistantRole, Content: reply},
+			Message{Role: UserRole, Content: retryPrompt(err)},
+		)
+	}
+}
+
+// fallbackMode 返回服务商拒绝 response_format 时改用的方式
+func fallbackMode(mode JSONMode, s *schema.Schema) JSONMode {
+	if mode == JSONModeSchema && s.RootType() == "object" {
+		return JSONModeObject
+	}
+	return JSONModePrompt
+}
+
+// responseFormat 返回请求体中的 response_format，只靠提示词时为 nil
+func responseFormat(s *schema.Schema, mode JSONMode) *ResponseFormat {
+	switch mode {
+	case JSONModeSchema:
+		return &ResponseFormat{Type: JSONModeSchema, JSONSchema: &JSONSchemaFormat{Name: s.Name(), Schema: s.Raw()}}
+	case JSONModeObject:
+		return &ResponseFormat{Type: JSONModeObject}
+	default:
+		return nil
+	}
+}
+
+// jsonMessages 复制消息列表并在系统提示词中要求只输出 JSON，不通过 response_format 传递 Schema 时附上 Schema。
+// json_object 方式要求提示词中出现 JSON 一词，这里的说明同时满足该要求
+func jsonMessages(messages []Message, s *schema.Schema, mode JSONMode) []Message {
+	instruction := "只输出一个 JSON 值，不要使用 Markdown 代码块，也不要输出任何解释。"
+	if mode != JSONModeSchema {
+		instruction = "只输出一个符合以下 JSON Schema 的 JSON 值，不要使用 Markdown 代码块，也不要输出任何解释。\nJSON Schema:\n" + string(s.Raw())
+	}
+
+	result := append([]Message(nil), messages...)
+	if len(result) > 0 && result[0].Role == SysRole && len(result[0].Parts) == 0 {
+		result[0].Content = strings.TrimSpace(result[0].Content + "\n\n" + instruction)
+		return result
+	}
+	return append([]Message{{Role: SysRole, Content: instruction}}, result...)
+}
+
+// retryPrompt 回答不符合 Schema 时重新提问的内容
+func retryPrompt(err error) string {
+	var sb strings.Builder
+	sb.WriteString("你的回答不符合要求：\n")
+	var validationErr *schema.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, problem := range validationErr.Problems {
+			sb.WriteString("- " + problem + "\n")
+		}
+	} else {
+		sb.WriteString("- " + err.Error() + "\n")
+	}
+	sb.WriteString("请修正后重新输出完整的 JSON，只输出 JSON。")
+	return sb.String()
+}
+
+// extractJSON 从回答中取出 JSON：去掉 Markdown 代码块，以及 JSON 对象或数组前后的说明文字
+func extractJSON(reply string) string {
+	reply = strings.TrimSpace(reply)
+	if json.Valid([]byte(reply)) {
+		return reply
+	}
+	if i := strings.Index(reply, "```"); i >= 0 {
+		block := reply[i+3:]
+		// 跳过代码块的语言标记，例如 ```json
+		if nl := strings.IndexByte(block, '\n'); nl >= 0 {
+			block = block[nl+1:]
+		}
+		if j := strings.Index(block, "```"); j >= 0 {
+			return strings.TrimSpace(block[:j])
+		}
+	}
+	start, end := strings.IndexAny(reply, "{["), strings.LastIndexAny(reply, "}]")
+	if start >= 0 && end > start {
+		return reply[start : end+1]
+	}
+	return reply
+}
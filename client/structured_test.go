@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sparrow-cli/global"
+	"sparrow-cli/schema"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const personSchema = `{"title":"person","type":"object","required":["name","age"],"properties":{"name":{"type":"string"},"age":{"type":"integer"}}}`
+
+// jsonServer 依次返回指定的回答，并记录收到的请求体
+type jsonServer struct {
+	mu       sync.Mutex
+	replies  []string
+	requests []RequestBody
+	reject   JSONMode // 以 400 拒绝该 response_format
+}
+
+func (s *jsonServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.requests = append(s.requests, body)
+	if body.ResponseFormat != nil && body.ResponseFormat.Type == s.reject {
+		http.Error(w, `{"error":{"message":"response_format is not supported"}}`, http.StatusBadRequest)
+		return
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	data, _ := json.Marshal(reply)
+	fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%s},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`, data)
+}
+
+func TestChatJSON(t *testing.T) {
+	s, err := schema.Parse([]byte(personSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []Message{{Role: SysRole, Content: "你是信息抽取助手"}, {Role: UserRole, Content: "张三今年三十岁"}}
+
+	t.Run("retry", func(t *testing.T) {
+		server := &jsonServer{replies: []string{"```json\n{\"name\":\"张三\",\"age\":\"三十\"}\n```", `结果如下：{"name":"张三","age":30}`}}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		var retried []error
+		c := New(&global.Model{Name: "gpt-4o", ApiKey: "sk-test", URL: ts.URL})
+		result, err := c.ChatJSON(context.Background(), JSONRequest{
+			Messages: messages,
+			Schema:   s,
+			Retries:  2,
+			OnRetry:  func(attempt int, err error) { retried = append(retried, err) },
+		})
+		if err != nil {
+			t.Fatalf("ChatJSON() error = %v", err)
+		}
+		if string(result.JSON) != `{"name":"张三","age":30}` || result.Attempts != 2 || result.Mode != JSONModeSchema {
+			t.Errorf("ChatJSON() = %s, attempts %d, mode %s", result.JSON, result.Attempts, result.Mode)
+		}
+		if result.Usage.TotalTokens != 30 {
+			t.Errorf("usage = %+v, want accumulated tokens", result.Usage)
+		}
+		if len(retried) != 1 || !strings.Contains(retried[0].Error(), "$.age: 应为 integer 类型") {
+			t.Errorf("OnRetry errors = %v", retried)
+		}
+
+		first := server.requests[0]
+		if first.ResponseFormat == nil || first.ResponseFormat.JSONSchema == nil || first.ResponseFormat.JSONSchema.Name != "person" ||
+			string(first.ResponseFormat.JSONSchema.Schema) != personSchema {
+			t.Errorf("response_format = %+v", first.ResponseFormat)
+		}
+		if len(first.Messages) != 2 || !strings.HasPrefix(first.Messages[0].Content, "你是信息抽取助手\n\n只输出一个 JSON 值") {
+			t.Errorf("first request messages = %+v", first.Messages)
+		}
+		second := server.requests[1].Messages
+		if len(second) != 4 || second[2].Role != AssistantRole || !strings.Contains(second[3].Content, "- $.age: 应为 integer 类型") {
+			t.Errorf("retry request messages = %+v", second)
+		}
+		if messages[0].Content != "你是信息抽取助手" {
+			t.Errorf("ChatJSON() modified the caller's messages")
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		server := &jsonServer{replies: []string{`{"name":"张三","age":30}`}, reject: JSONModeSchema}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		c := New(&global.Model{Name: "llama3", URL: ts.URL, Provider: global.ProviderOllama})
+		result, err := c.ChatJSON(context.Background(), JSONRequest{Messages: messages[1:], Schema: s})
+		if err != nil {
+			t.Fatalf("ChatJSON() error = %v", err)
+		}
+		if result.Mode != JSONModeObject || result.Attempts != 1 {
+			t.Errorf("mode = %s, attempts = %d, want json_object after fallback", result.Mode, result.Attempts)
+		}
+		last := server.requests[len(server.requests)-1]
+		if last.ResponseFormat == nil || last.ResponseFormat.Type != JSONModeObject || last.Messages[0].Role != SysRole ||
+			!strings.Contains(last.Messages[0].Content, personSchema) {
+			t.Errorf("fallback request = %+v", last)
+		}
+	})
+
+	t.Run("give up", func(t *testing.T) {
+		server := &jsonServer{replies: []string{`{"name":"张三"}`, `不知道`}}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		c := New(&global.Model{Name: "deepseek-chat", ApiKey: "sk-test", URL: ts.URL, Provider: global.ProviderDeepSeek})
+		_, err := c.ChatJSON(context.Background(), JSONRequest{Messages: messages, Schema: s, Retries: 1})
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) || schemaErr.Attempts != 2 || schemaErr.Reply != "不知道" {
+			t.Fatalf("ChatJSON() error = %v, want *SchemaError after 2 attempts", err)
+		}
+		if server.requests[0].ResponseFormat == nil || server.requests[0].ResponseFormat.Type != JSONModeObject {
+			t.Errorf("deepseek should use json_object, got %+v", server.requests[0].ResponseFormat)
+		}
+	})
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"a":1}`:                   `{"a":1}`,
+		"  \"text\"  ":              `"text"`,
+		"```json\n{\"a\":1}\n```":   `{"a":1}`,
+		"结果：\n```\n[1,2]\n```\n以上。": `[1,2]`,
+		`好的，{"a":{"b":[1]}} 希望有帮助`:  `{"a":{"b":[1]}}`,
+		"不知道":                       "不知道",
+	}
+	for reply, want := range tests {
+		if got := extractJSON(reply); got != want {
+			t.Errorf("extractJSON(%q) = %q, want %q", reply, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sparrow-cli/global"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultConnectTimeout   = 30 * time.Second // 默认的建立连接超时时间
+	defaultFirstByteTimeout = 3 * time.Minute  // 默认的等待响应头超时时间，本地模型首次加载可能较慢
+	defaultIdleTimeout      = 2 * time.Minute  // 默认的流式输出间隔超时时间
+)
+
+var (
+	httpClients     = make(map[global.Transport]*http.Client) // 按传输设置缓存的 HTTP 客户端，以便复用连接
+	httpClientsLock sync.Mutex
+
+	// wrapTransport 包装所有模型请求的传输层，用于录制与回放，为 nil 时不包装
+	wrapTransport func(http.RoundTripper) http.RoundTripper
+)
+
+// WrapTransport 设置包装所有模型请求传输层的函数，例如录制或回放请求，传入 nil 时取消包装。
+// 只影响之后创建的客户端，通过 WithHTTPClient 指定的 HTTP 客户端不受影响。
+func WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	httpClientsLock.Lock()
+	defer httpClientsLock.Unlock()
+	wrapTransport = wrap
+	clear(httpClients)
+}
+
+// httpClientFor 返回符合传输设置的 HTTP 客户端，相同设置的模型共用同一个客户端
+func httpClientFor(t global.Transport) (*http.Client, error) {
+	httpClientsLock.Lock()
+	defer httpClientsLock.Unlock()
+
+	if c, ok := httpClients[t]; ok {
+		return c, nil
+	}
+	transport, err := newTransport(t)
+	if err != nil {
+		return nil, err
+	}
+	var roundTripper http.RoundTripper = transport
+	if wrapTransport != nil {
+		roundTripper = wrapTransport(transport)
+	}
+	// 不设置 http.Client.Timeout，它会限制读取整个流式回答的时间，超时由 Client 分阶段控制
+	c := &http.Client{Transport: roundTripper}
+	httpClients[t] = c
+	return c, nil
+}
+
+// newTransport 按传输设置创建 http.Transport
+func newTransport(t global.Transport) (*http.Transport, error) {
+	connectTimeout := durationOr(t.ConnectTimeout, defaultConnectTimeout)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+
+	if t.Proxy != "" {
+		proxy, err := url.Parse(t.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址无效 %s: %w", t.Proxy, err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("不支持的代理类型 %q，可选: http、https、socks5", proxy.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if t.CAFile == "" && t.CertFile == "" && t.KeyFile == "" && !t.InsecureSkipVerify {
+		return transport, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA 证书文件 %s 中没有有效的 PEM 证书", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// durationOr 未设置时返回默认时长
+func durationOr(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
+
+// watchdog 超时后取消请求，用于分阶段限制等待响应头与流式输出间隔的时间
+type watchdog struct {
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// newWatchdog 创建尚未启动的计时器，超时后调用 cancel
+func newWatchdog(cancel context.CancelFunc) *watchdog {
+	w := &watchdog{}
+	w.timer = time.AfterFunc(time.Hour, func() {
+		w.expired.Store(true)
+		cancel()
+	})
+	w.timer.Stop()
+	return w
+}
+
+// reset 重新开始计时，d 不大于 0 时停止计时
+func (w *watchdog) reset(d time.Duration) {
+	if d <= 0 {
+		w.timer.Stop()
+		return
+	}
+	w.timer.Reset(d)
+}
+
+// stop 停止计时
+func (w *watchdog) stop() {
+	w.timer.Stop()
+}
+
+// idleReader 每次读到数据后重新开始计时，流式输出长时间没有新数据时中断请求
+type idleReader struct {
+	io.ReadCloser
+	watchdog *watchdog
+	timeout  time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.watchdog.reset(r.timeout)
+	}
+	return n, err
+}
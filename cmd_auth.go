@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sparrow-cli/config"
+	"sparrow-cli/credential"
+	"sparrow-cli/env"
+)
+
+func init() {
+	registerSubcommand(&subcommand{
+		name:  "auth",
+		usage: "add|list|remove|rotate [名称]",
+		desc:  "管理加密保存的 API 密钥，模型配置通过 credential 字段引用",
+		raw:   true,
+		run:   runAuth,
+	})
+}
+
+// runAuth 分发 auth 子命令
+func runAuth(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: auth add|list|remove|rotate [名称]")
+	}
+	if args[0] != "list" && len(args) < 2 {
+		return fmt.Errorf("用法: auth %s <名称>", args[0])
+	}
+
+	path := credential.DefaultPath(env.SparrowCliHome)
+	if args[0] == "list" && !credential.Exists(path) {
+		fmt.Println("尚未保存任何凭据")
+		return nil
+	}
+
+	store, err := credential.Unlock(path)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		names := store.Names()
+		if len(names) == 0 {
+			fmt.Println("尚未保存任何凭据")
+		}
+		for _, name := range names {
+			info, _ := store.Info(name)
+			fmt.Printf("%-20s %-12s 创建于 %s，更新于 %s\n", name, config.MaskSecret(info.Secret),
+				info.CreatedAt.Format("2006-01-02 15:04"), info.UpdatedAt.Format("2006-01-02 15:04"))
+		}
+		return nil
+
+	case "add":
+		secret, err := credential.ReadSecret(fmt.Sprintf("请输入 %s 的 API 密钥: ", args[1]))
+		if err != nil {
+			return err
+		}
+		if secret == "" {
+			return fmt.Errorf("密钥不能为空")
+		}
+		if err := store.Add(args[1], secret); err != nil {
+			return err
+		}
+
+	case "rotate":
+		if _, ok := store.Get(args[1]); !ok {
+			return fmt.Errorf("凭据不存在: %s", args[1])
+		}
+		secret, err := credential.ReadSecret(fmt.Sprintf("请输入 %s 的新 API 密钥: ", args[1]))
+		if err != nil {
+			return err
+		}
+		if secret == "" {
+			return fmt.Errorf("密钥不能为空")
+		}
+		if err := store.Rotate(args[1], secret); err != nil {
+			return err
+		}
+
+	case "remove":
+		if err := store.Remove(args[1]); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("未知的 auth 子命令: %s", args[0])
+	}
+
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("✓ 已更新凭据 %s\n", args[1])
+	return nil
+}
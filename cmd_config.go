@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sparrow-cli/config"
+	"sparrow-cli/file"
+	"sparrow-cli/terminal"
+	"strings"
+)
+
+func init() {
+	registerSubcommand(&subcommand{
+		name:  "config",
+		usage: "show|validate|migrate|get|set|unset|list|edit",
+		desc:  "查看、校验、升级与修改配置，migrate/get/set/unset/list/edit 默认操作家目录配置，--project 操作当前目录的项目配置",
+		raw:   true,
+		run:   runConfig,
+	})
+}
+
+// runConfig 分发 config 子命令
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: config show|validate|migrate|get|set|unset|list|edit")
+	}
+
+	switch args[0] {
+	case "show":
+		return showConfig()
+	case "validate":
+		return validateConfig()
+	case "migrate", "get", "set", "unset", "list", "edit":
+		return editConfigFile(args[0], args[1:])
+	default:
+		return fmt.Errorf("未知的 config 子命令: %s", args[0])
+	}
+}
+
+// showConfig 输出合并后的有效配置
+func showConfig() error {
+	if err := config.LoadConfig(); err != nil {
+		return err
+	}
+	data, err := config.Effective()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("# 配置文件（优先级从低到高）：")
+	for _, path := range config.Current().Sources {
+		fmt.Printf("#   %s\n", path)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// validateConfig 校验所有配置文件，逐条输出问题及其位置
+func validateConfig() error {
+	paths := config.Paths()
+	for _, path := range paths {
+		if file.IsExist(path) {
+			fmt.Printf("检查 %s\n", path)
+		}
+	}
+
+	problems := config.Validate(paths)
+	if len(problems) == 0 {
+		fmt.Println("✓ 配置有效")
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Println("  ✗ " + p.String())
+	}
+	return fmt.Errorf("发现 %d 个问题", len(problems))
+}
+
+// editConfigFile 处理 get/set/unset/list/edit 子命令
+func editConfigFile(action string, args []string) error {
+	fs := flag.NewFlagSet("config "+action, flag.ContinueOnError)
+	project := fs.Bool("project", false, "修改当前目录的项目配置 "+config.ProjectConfigFileName)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	path := config.HomeConfigPath()
+	if *project {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(cwd, config.ProjectConfigFileName)
+	}
+	switch action {
+	case "edit":
+		return editInEditor(path)
+	case "migrate":
+		return migrateConfig(path)
+	}
+
+	doc, err := config.OpenDocument(path)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "get":
+		if len(args) != 1 {
+			return fmt.Errorf("用法: config get <路径>，例如 config get models[0].url")
+		}
+		n, err := doc.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(config.NodeString(n))
+		return nil
+
+	case "list":
+		for _, e := range doc.List() {
+			value := e.Value
+			if filepath.Ext(e.Path) == ".api_key" || strings.Contains(e.Path, ".headers.") {
+				value = config.MaskSecret(value)
+			}
+			fmt.Printf("%s = %s\n", e.Path, value)
+		}
+		return nil
+
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("用法: config set <路径> <值>，例如 config set logger.level debug")
+		}
+		err = doc.Set(args[0], args[1])
+	case "unset":
+		if len(args) != 1 {
+			return fmt.Errorf("用法: config unset <路径>")
+		}
+		err = doc.Unset(args[0])
+	}
+	if err != nil {
+		return err
+	}
+	if err := doc.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("✓ 已更新 %s\n", path)
+	return nil
+}
+
+// migrateConfig 将配置文件升级到当前版本并报告修改内容
+func migrateConfig(path string) error {
+	result, err := config.Migrate(path)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		fmt.Printf("%s 已是最新版本 %d\n", path, config.CurrentVersion)
+		return nil
+	}
+	fmt.Println("✓ " + result.String())
+	return nil
+}
+
+// editInEditor 使用 $VISUAL 或 $EDITOR 编辑配置文件的临时副本，校验通过后才替换原文件
+func editInEditor(path string) error {
+	editorCmd := os.Getenv("VISUAL")
+	if editorCmd == "" {
+		editorCmd = os.Getenv("EDITOR")
+	}
+	if editorCmd == "" {
+		editorCmd = "vi"
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取配置文件失败 %s: %w", path, err)
+	}
+
+	// 临时文件由 CreateTemp 以 0600 权限创建，避免泄露配置中的密钥
+	tmp, err := os.CreateTemp("", "sparrow-cli-config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+
+	prompter := terminal.NewEditor(nil)
+	for {
+		cmd := exec.Command("sh", "-c", editorCmd+` "$1"`, "sh", tmpPath)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("运行编辑器 %s 失败: %w", editorCmd, err)
+		}
+
+		edited, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("读取临时文件失败: %w", err)
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("配置未修改")
+			return nil
+		}
+
+		problems := config.ValidateData(path, edited)
+		if len(problems) == 0 {
+			if err := config.WriteConfigFile(path, edited); err != nil {
+				return err
+			}
+			fmt.Printf("✓ 已更新 %s\n", path)
+			return nil
+		}
+		for _, p := range problems {
+			fmt.Println("  ✗ " + p.String())
+		}
+		if !prompter.Confirm("配置存在问题，是否重新编辑？") {
+			return fmt.Errorf("修改未保存")
+		}
+	}
+}
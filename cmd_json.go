@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sparrow-cli/client"
+	"sparrow-cli/config"
+	"sparrow-cli/file"
+	"sparrow-cli/global"
+	"sparrow-cli/schema"
+	"sparrow-cli/terminal"
+	"strings"
+)
+
+// defaultJSONRetries 回答不符合 Schema 时默认重新提问的次数
+const defaultJSONRetries = 2
+
+func init() {
+	registerSubcommand(&subcommand{
+		name:  "json",
+		usage: "--schema <文件> [--model 名称] [--output 文件] [问题]",
+		desc:  "要求模型输出符合 JSON Schema 的 JSON 并在本地校验，标准输入的内容会附加在问题之后",
+		run:   runJSON,
+	})
+}
+
+// runJSON 从命令行参数与标准输入读取问题，输出通过校验的 JSON
+func runJSON(args []string) error {
+	fs := flag.NewFlagSet("json", flag.ContinueOnError)
+	schemaPath := fs.String("schema", "", "JSON Schema 文件，支持 JSON 与 YAML 格式")
+	modelName := fs.String("model", "", "使用的模型，默认为启动时的默认模型")
+	output := fs.String("output", "", "将 JSON 写入新文件，文件已存在时不覆盖，不指定时输出到标准输出")
+	retries := fs.Int("retries", defaultJSONRetries, "回答不符合 Schema 时重新提问的最大次数")
+	temperature := fs.Float64("temperature", 0, "生成文本的随机性控制参数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *schemaPath == "" {
+		return fmt.Errorf("缺少 --schema 参数")
+	}
+	if *retries < 0 {
+		return fmt.Errorf("--retries 不能为负数")
+	}
+	s, err := schema.Load(*schemaPath)
+	if err != nil {
+		return err
+	}
+
+	question := strings.Join(fs.Args(), " ")
+	if !terminal.IsTerminal(os.Stdin) {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("读取标准输入失败: %w", err)
+		}
+		if text := strings.TrimSpace(string(input)); text != "" {
+			question = strings.TrimSpace(question + "\n\n" + text)
+		}
+	}
+	if question == "" {
+		return fmt.Errorf("缺少问题，可以在参数中给出或通过标准输入传入")
+	}
+
+	// 标准输入不是终端时无法询问，tools.write_file 为 ask 时拒绝写入
+	var editor *terminal.Editor
+	if terminal.IsTerminal(os.Stdin) {
+		editor = terminal.NewEditor(nil)
+	}
+	if ok, err := confirmOutput(editor, config.Current().Tools, *output); !ok {
+		return err
+	}
+
+	name := *modelName
+	if name == "" {
+		name = currentModelName()
+	}
+	model, err := config.Current().Model(name)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return askJSON(ctx, model, client.JSONRequest{
+		Messages:    []client.Message{{Role: client.UserRole, Content: question}},
+		Temperature: *temperature,
+		Schema:      s,
+		Retries:     *retries,
+	}, *output)
+}
+
+// confirmOutput 检查 JSON 的输出文件：与 /save 相同，文件已存在时不覆盖，并按 tools.write_file 权限决定是否写入。
+// 在发送请求前调用，以免得到回答后才发现无法写入
+// param editor 为询问用户的编辑器，为 nil 时无法询问。
+// param tools 为当前生效的工具权限。
+// param output 为输出文件，为空时输出到标准输出，不需要检查。
+//
+// return 是否继续和可能的错误，用户取消时返回 false 与 nil。
+func confirmOutput(editor *terminal.Editor, tools config.ToolsConfigData, output string) (bool, error) {
+	if output == "" {
+		return true, nil
+	}
+	if file.IsExist(output) {
+		return false, fmt.Errorf("文件已存在: %s", output)
+	}
+	return checkPermission(editor, tools.WriteFile.Or(config.DefaultTools.WriteFile), fmt.Sprintf("确认将 JSON 写入 %s？", output))
+}
+
+// askJSON 请求符合 Schema 的 JSON，格式化后输出到标准输出或写入文件，过程信息输出到标准错误
+func askJSON(ctx context.Context, model *global.Model, req client.JSONRequest, output string) error {
+	req.OnRetry = func(attempt int, err error) {
+		fmt.Fprintf(os.Stderr, "✗ 第 %d 次回答不符合要求，重新提问:\n", attempt)
+		var validationErr *schema.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, problem := range validationErr.Problems {
+				fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "  - %v\n", err)
+		}
+	}
+
+	result, err := client.New(model).ChatJSON(ctx, req)
+	var schemaErr *client.SchemaError
+	if errors.As(err, &schemaErr) {
+		fmt.Fprintf(os.Stderr, "最后一次回答:\n%s\n", schemaErr.Reply)
+	}
+	if err != nil {
+		return err
+	}
+
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, result.JSON, "", "  "); err != nil {
+		return err
+	}
+	formatted.WriteByte('\n')
+
+	summary := fmt.Sprintf("模型: %s（%s），提问 %d 次，Token使用: 输入=%d, 输出=%d",
+		model.Name, result.Mode, result.Attempts, result.Usage.PromptTokens, result.Usage.CompletionTokens)
+	if cost, ok := model.Cost(result.Usage.PromptTokens, result.Usage.CompletionTokens); ok {
+		summary += fmt.Sprintf("，费用: %s%.6f", model.Currency, cost)
+	}
+	if output == "" {
+		os.Stdout.Write(formatted.Bytes())
+		fmt.Fprintln(os.Stderr, summary)
+		return nil
+	}
+	if err := file.WriteNewFile(output, formatted.Bytes()); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✓ 已保存到 %s\n%s\n", output, summary)
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sparrow-cli/mock"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerSubcommand(&subcommand{
+		name:  "mock-server",
+		usage: "[--addr 地址] [--script 文件]",
+		desc:  "启动兼容 OpenAI 接口的模拟服务，用于演示、离线开发与集成测试",
+		raw:   true,
+		run:   runMockServer,
+	})
+}
+
+// runMockServer 启动模拟服务，直到收到中断信号
+func runMockServer(args []string) error {
+	fs := flag.NewFlagSet("mock-server", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "监听地址")
+	scriptPath := fs.String("script", "", "脚本化回答的 YAML 文件，不指定时回显用户消息")
+	models := fs.String("models", "", "提供的模型，以逗号分隔，不指定时接受任意模型")
+	apiKey := fs.String("api-key", "", "要求请求携带的 API 密钥，不指定时不校验")
+	latency := fs.Duration("latency", 0, "每个请求返回响应前的延迟，例如 500ms")
+	chunkDelay := fs.Duration("chunk-delay", 30*time.Millisecond, "流式回答两个数据块之间的间隔")
+	errorRate := fs.Float64("error-rate", 0, "随机返回 500 错误的概率，取值 0 到 1")
+	rateLimit := fs.Int("rate-limit", 0, "每个时间窗口内允许的请求数，超出时返回 429，为 0 时不限流")
+	rateWindow := fs.Duration("rate-window", time.Minute, "限流的时间窗口")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *errorRate < 0 || *errorRate > 1 {
+		return fmt.Errorf("--error-rate 应在 0 到 1 之间")
+	}
+
+	opts := mock.Options{
+		APIKey:     *apiKey,
+		Latency:    *latency,
+		ChunkDelay: *chunkDelay,
+		ErrorRate:  *errorRate,
+		RateLimit:  *rateLimit,
+		RateWindow: *rateWindow,
+	}
+	if *models != "" {
+		for _, name := range strings.Split(*models, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.Models = append(opts.Models, name)
+			}
+		}
+	}
+	if *scriptPath != "" {
+		script, err := mock.LoadScript(*scriptPath)
+		if err != nil {
+			return err
+		}
+		opts.Script = script
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", *addr, err)
+	}
+	handler := mock.New(opts)
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	url := "http://" + listener.Addr().String() + "/v1/chat/completions"
+	fmt.Printf("模拟服务已启动: %s\n", url)
+	fmt.Println("在配置中添加以下模型即可使用，按 Ctrl-C 停止:")
+	// 未要求密钥时任意值都可以，填写占位值以通过配置校验
+	key := *apiKey
+	if key == "" {
+		key = "mock"
+	}
+	fmt.Printf("  - model: %s\n    url: %s\n    api_key: %s\n", handler.Models()[0], url, key)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("模拟服务已停止")
+	return nil
+}
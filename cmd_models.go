@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sparrow-cli/catalog"
+	"sparrow-cli/client"
+	"sparrow-cli/config"
+	"sparrow-cli/env"
+	"sparrow-cli/global"
+	"sparrow-cli/terminal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listModelsTimeout 查询单个服务模型列表的超时时间
+const listModelsTimeout = 30 * time.Second
+
+func init() {
+	registerSubcommand(&subcommand{
+		name:  "models",
+		usage: "[--refresh] [--add 模型,...] [关键词]",
+		desc:  "查询已配置服务提供的模型列表，并可将其中的模型添加到配置",
+		run:   runModels,
+	})
+}
+
+// modelEndpoint 一个模型服务，多个已配置的模型可能使用同一个服务
+type modelEndpoint struct {
+	url        string             // 模型列表地址
+	model      *global.Model      // 用于查询的模型，提供地址、密钥与传输设置
+	configured []string           // 使用该服务的已配置模型
+	models     []client.ModelInfo // 服务提供的模型
+	fetchedAt  time.Time          // 模型列表的查询时间
+	cached     bool               // 模型列表是否来自缓存
+	err        error              // 查询失败的原因
+}
+
+// discoveredModel 服务提供但尚未配置的模型，可以添加到配置
+type discoveredModel struct {
+	endpoint *modelEndpoint
+	name     string
+}
+
+// runModels 列出各服务提供的模型，--add 直接添加模型，否则在终端中询问要添加的模型
+func runModels(args []string) error {
+	fs := flag.NewFlagSet("models", flag.ContinueOnError)
+	refresh := fs.Bool("refresh", false, "忽略缓存，重新查询模型列表")
+	add := fs.String("add", "", "将服务提供的模型添加到配置，多个以逗号分隔")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	endpoints := modelEndpoints(config.Current())
+	fetchEndpoints(context.Background(), endpoints, *refresh)
+	// 标准输入不是终端时无法询问，Azure 模型因缺少部署名称而跳过
+	var editor *terminal.Editor
+	if terminal.IsTerminal(os.Stdin) {
+		editor = terminal.NewEditor(nil)
+	}
+	if *add != "" {
+		return addModels(editor, endpoints, strings.Split(*add, ","))
+	}
+
+	discovered := printEndpoints(endpoints, strings.Join(fs.Args(), " "))
+	if len(discovered) > 0 && editor != nil {
+		offerModels(editor, discovered)
+	}
+	return nil
+}
+
+// modelEndpoints 按模型列表地址对已配置的模型分组
+func modelEndpoints(c *config.Config) []*modelEndpoint {
+	var endpoints []*modelEndpoint
+	byURL := make(map[string]*modelEndpoint)
+	for _, m := range c.Models {
+		model := m.ToModel()
+		url, err := client.ModelsURL(model)
+		if err != nil {
+			endpoints = append(endpoints, &modelEndpoint{url: m.URL, model: model, configured: []string{m.Model}, err: err})
+			continue
+		}
+		if e, ok := byURL[url]; ok {
+			e.configured = append(e.configured, m.Model)
+			continue
+		}
+		e := &modelEndpoint{url: url, model: model, configured: []string{m.Model}}
+		byURL[url] = e
+		endpoints = append(endpoints, e)
+	}
+	return endpoints
+}
+
+// fetchEndpoints 并发查询各服务的模型列表，缓存未过期且不要求刷新时直接使用缓存
+func fetchEndpoints(ctx context.Context, endpoints []*modelEndpoint, refresh bool) {
+	path := catalog.DefaultPath(env.SparrowCliHome)
+	cache, err := catalog.Load(path)
+	if err != nil {
+		fmt.Printf("警告: %v，将重新查询\n", err)
+		cache = catalog.New(path)
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	for _, e := range endpoints {
+		if e.err != nil {
+			continue
+		}
+		if entry, ok := cache.Get(e.url, catalog.DefaultTTL); ok && !refresh {
+			e.models, e.fetchedAt, e.cached = entry.Models, entry.FetchedAt, true
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			models, err := client.New(e.model, client.WithTimeout(listModelsTimeout)).ListModels(ctx)
+			if err != nil {
+				e.err = err
+				return
+			}
+			e.models, e.fetchedAt = models, time.Now()
+			lock.Lock()
+			cache.Put(e.url, models)
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if err := cache.Save(); err != nil {
+		fmt.Printf("警告: 保存模型列表缓存失败: %v\n", err)
+	}
+}
+
+// printEndpoints 输出各服务中名称包含关键词的模型，返回尚未配置的模型，顺序与输出的编号一致
+func printEndpoints(endpoints []*modelEndpoint, keyword string) []discoveredModel {
+	var discovered []discoveredModel
+	for _, e := range endpoints {
+		fmt.Printf("── %s ──\n", e.url)
+		if e.err != nil {
+			fmt.Printf("  ✗ 查询失败: %v\n", e.err)
+			continue
+		}
+		source := "刚刚查询"
+		if e.cached {
+			source = "缓存于 " + e.fetchedAt.Format("2006-01-02 15:04") + "，使用 --refresh 或 /models refresh 重新查询"
+		}
+		fmt.Printf("  %d 个模型（%s）\n", len(e.models), source)
+
+		for _, m := range e.models {
+			if keyword != "" && !strings.Contains(strings.ToLower(m.ID), strings.ToLower(keyword)) {
+				continue
+			}
+			label := "  ✓ "
+			if !slices.Contains(e.configured, m.ID) {
+				discovered = append(discovered, discoveredModel{endpoint: e, name: m.ID})
+				label = fmt.Sprintf("%3d ", len(discovered))
+			}
+			line := fmt.Sprintf("  %s %-36s %-8s %s", label, m.ID, formatContextLength(m.ContextLength), strings.Join(m.Capabilities, ", "))
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+	return discovered
+}
+
+// offerModels 询问要添加到配置的模型编号
+func offerModels(editor *terminal.Editor, discovered []discoveredModel) {
+	answer, err := editor.Prompt("输入要添加到配置的模型编号，多个以逗号分隔，直接回车跳过：")
+	if err != nil || strings.TrimSpace(answer) == "" {
+		return
+	}
+	for _, field := range strings.Split(answer, ",") {
+		field = strings.TrimSpace(field)
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(discovered) {
+			fmt.Printf("✗ 无效的编号: %s\n", field)
+			continue
+		}
+		addModel(editor, discovered[n-1].endpoint, discovered[n-1].name)
+	}
+}
+
+// addModels 按名称添加服务提供的模型，名称必须出现在某个服务的模型列表中
+func addModels(editor *terminal.Editor, endpoints []*modelEndpoint, names []string) error {
+	failed := 0
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var found *modelEndpoint
+		for _, e := range endpoints {
+			for _, m := range e.models {
+				if m.ID == name {
+					found = e
+					break
+				}
+			}
+			if found != nil {
+				break
+			}
+		}
+		if found == nil {
+			fmt.Printf("✗ 没有服务提供模型 %s\n", name)
+			failed++
+			continue
+		}
+		if !addModel(editor, found, name) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个模型添加失败", failed)
+	}
+	return nil
+}
+
+// addModel 以服务中第一个已配置的模型为模板添加模型并重新加载配置。
+// Azure 的模型列表列出的是基础模型而不是部署，请求需要使用部署名称，因此先询问部署名称，
+// editor 为 nil 或未输入时跳过该模型
+func addModel(editor *terminal.Editor, e *modelEndpoint, name string) bool {
+	deployment := ""
+	if e.model.Provider == global.ProviderAzure {
+		if editor == nil {
+			fmt.Printf("✗ 跳过模型 %s: Azure 模型需要部署名称，请在终端中运行 models 命令添加，或在配置中设置 deployment\n", name)
+			return false
+		}
+		answer, err := editor.Prompt(fmt.Sprintf("模型 %s 在 Azure 中的部署名称，直接回车跳过：", name))
+		if deployment = strings.TrimSpace(answer); err != nil || deployment == "" {
+			fmt.Printf("已跳过模型 %s\n", name)
+			return false
+		}
+	}
+
+	path, err := config.AddModel(e.configured[0], name, deployment)
+	if err != nil {
+		fmt.Printf("✗ 添加模型 %s 失败: %v\n", name, err)
+		return false
+	}
+	e.configured = append(e.configured, name)
+	if _, err := config.Reload(); err != nil {
+		fmt.Printf("警告: 重新加载配置失败: %v\n", err)
+	}
+	fmt.Printf("✓ 已将模型 %s 添加到 %s\n", name, path)
+	return true
+}
+
+// formatContextLength 格式化上下文长度，例如 128K，未知时返回 -
+func formatContextLength(n int) string {
+	switch {
+	case n <= 0:
+		return "-"
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dM", n>>20)
+	case n >= 1000000 && n%1000000 == 0:
+		return fmt.Sprintf("%dM", n/1000000)
+	case n >= 1024 && n%1024 == 0:
+		return fmt.Sprintf("%dK", n>>10)
+	case n >= 1000:
+		return fmt.Sprintf("%dK", n/1000)
+	default:
+		return strconv.Itoa(n)
+	}
+}
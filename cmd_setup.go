@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sparrow-cli/client"
+	"sparrow-cli/config"
+	"sparrow-cli/credential"
+	"sparrow-cli/env"
+	"sparrow-cli/file"
+	"sparrow-cli/global"
+	"sparrow-cli/terminal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setupTestTimeout 配置向导测试连接的超时时间
+const setupTestTimeout = 30 * time.Second
+
+// providerPreset 配置向导中服务商的默认接口地址与模型
+type providerPreset struct {
+	provider global.Provider
+	url      string
+	model    string
+	needsKey bool
+}
+
+// providerPresets 配置向导可选的服务商
+var providerPresets = []providerPreset{
+	{global.ProviderOpenAI, "https://api.openai.com/v1/chat/completions", "gpt-4o", true},
+	{global.ProviderDeepSeek, "https://api.deepseek.com/chat/completions", "deepseek-chat", true},
+	{global.ProviderQwen, "https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions", "qwen-plus", true},
+	{global.ProviderZhipu, "https://open.bigmodel.cn/api/paas/v4/chat/completions", "glm-4-flash", true},
+	{global.ProviderOllama, "http://localhost:11434/v1/chat/completions", "llama3", false},
+	{global.ProviderAzure, "https://<资源名称>.openai.azure.com", "gpt-4o", true},
+}
+
+func init() {
+	registerSubcommand(&subcommand{
+		name:  "setup",
+		usage: "",
+		desc:  "交互式配置向导，选择服务商、填写接口与密钥并测试连接后生成配置文件",
+		raw:   true,
+		run: func(args []string) error {
+			return runSetup()
+		},
+	})
+}
+
+// runSetup 运行配置向导并写入家目录配置文件
+func runSetup() error {
+	path := config.HomeConfigPath()
+	if !terminal.IsTerminal(os.Stdin) {
+		return fmt.Errorf("配置向导需要在终端中运行，也可以直接编辑 %s", path)
+	}
+
+	editor := terminal.NewEditor(nil)
+	if file.IsExist(path) && !editor.Confirm(fmt.Sprintf("配置文件 %s 已存在，是否覆盖？", path)) {
+		fmt.Println("已取消")
+		return nil
+	}
+
+	fmt.Println("欢迎使用 sparrow-cli，接下来将引导你完成模型配置，按 Ctrl-C 可随时退出。")
+	var (
+		m      config.ModelConfig
+		secret string
+	)
+	for {
+		preset, err := askProvider(editor)
+		if err != nil {
+			return err
+		}
+		m.Provider = string(preset.provider)
+		m.Deployment, m.APIVersion = "", ""
+		if m.URL, err = askWithDefault(editor, "接口地址", preset.url); err != nil {
+			return err
+		}
+		if m.Model, err = askWithDefault(editor, "模型名称", preset.model); err != nil {
+			return err
+		}
+		if preset.provider == global.ProviderAzure {
+			if m.Deployment, err = askWithDefault(editor, "部署名称", m.Model); err != nil {
+				return err
+			}
+			if m.APIVersion, err = askWithDefault(editor, "接口版本", client.DefaultAzureAPIVersion); err != nil {
+				return err
+			}
+		}
+
+		m.ApiKey, secret = "", ""
+		if preset.needsKey {
+			fmt.Println("API 密钥可直接输入，也可以填写 ${环境变量}、file:<路径> 或 cmd:<命令> 引用。")
+			if m.ApiKey, err = credential.ReadSecret("请输入 API 密钥: "); err != nil {
+				return err
+			}
+			if secret, err = config.ResolveSecret(m.ApiKey); err != nil {
+				fmt.Printf("警告: %v\n", err)
+			}
+		}
+
+		fmt.Println("正在测试连接...")
+		testErr := testConnection(m, secret)
+		if testErr == nil {
+			fmt.Println("✓ 连接成功")
+			break
+		}
+		fmt.Printf("✗ 连接测试失败: %v\n", testErr)
+		if editor.Confirm("是否重新填写？") {
+			continue
+		}
+		if !editor.Confirm("仍然保存当前配置？") {
+			fmt.Println("已取消")
+			return nil
+		}
+		break
+	}
+
+	// 明文密钥建议保存到加密凭据存储，配置文件中只记录凭据名称
+	if isPlainSecret(m.ApiKey) && editor.Confirm("是否将密钥保存到加密凭据存储（推荐）？") {
+		if err := storeCredential(m.Provider, m.ApiKey); err != nil {
+			fmt.Printf("✗ 保存凭据失败: %v，密钥将写入配置文件\n", err)
+		} else {
+			m.Credential, m.ApiKey = m.Provider, ""
+		}
+	}
+
+	// 连接测试失败时也可能选择保存，写入前校验生成的配置，有问题的配置项重新询问
+	for {
+		data, err := config.RenderInitialConfig(m)
+		if err != nil {
+			return err
+		}
+		problems := config.ValidateData(path, data)
+		if len(problems) == 0 {
+			if err := config.WriteConfigFile(path, data); err != nil {
+				return err
+			}
+			fmt.Printf("✓ 配置已写入 %s\n", path)
+			return nil
+		}
+		fmt.Println("✗ 配置无效，未保存:")
+		for _, p := range problems {
+			fmt.Printf("  %s\n", p)
+		}
+		if err := askInvalidFields(editor, path, &m, problems); err != nil {
+			return err
+		}
+	}
+}
+
+// modelProblemPattern 匹配模型配置项的校验问题，分组 1 为配置项，分组 2 为缺少的配置项
+var modelProblemPattern = regexp.MustCompile(`^models\[\d+\](?:\.(\w+) | 缺少 (api_key) )`)
+
+// setupFields 配置向导可以重新询问的配置项
+var setupFields = []struct {
+	key   string
+	label string
+	value func(m *config.ModelConfig) *string
+}{
+	{"model", "模型名称", func(m *config.ModelConfig) *string { return &m.Model }},
+	{"url", "接口地址", func(m *config.ModelConfig) *string { return &m.URL }},
+	{"deployment", "部署名称", func(m *config.ModelConfig) *string { return &m.Deployment }},
+	{"api_version", "接口版本", func(m *config.ModelConfig) *string { return &m.APIVersion }},
+}
+
+// askInvalidFields 重新询问校验问题涉及的配置项
+//
+// return 问题不涉及向导中填写的配置项、无法通过重新填写解决时返回校验错误。
+func askInvalidFields(editor *terminal.Editor, path string, m *config.ModelConfig, problems []config.Problem) error {
+	asked := false
+	for _, f := range setupFields {
+		if !mentionsField(problems, path, f.key) {
+			continue
+		}
+		value := f.value(m)
+		answer, err := askWithDefault(editor, f.label, *value)
+		if err != nil {
+			return err
+		}
+		*value, asked = answer, true
+	}
+	if mentionsField(problems, path, "api_key") {
+		key, err := credential.ReadSecret("请重新输入 API 密钥: ")
+		if err != nil {
+			return err
+		}
+		m.ApiKey, m.Credential, asked = key, "", true
+	}
+	if !asked {
+		return &config.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// mentionsField 判断配置文件 path 的校验问题是否涉及模型的指定配置项，缺少密钥视为涉及 api_key
+func mentionsField(problems []config.Problem, path, key string) bool {
+	for _, p := range problems {
+		if filepath.Clean(p.File) != filepath.Clean(path) {
+			continue
+		}
+		if m := modelProblemPattern.FindStringSubmatch(p.Message); m != nil && (m[1] == key || m[2] == key) {
+			return true
+		}
+	}
+	return false
+}
+
+// askProvider 询问服务商
+func askProvider(editor *terminal.Editor) (providerPreset, error) {
+	fmt.Println("请选择模型服务商：")
+	for i, p := range providerPresets {
+		fmt.Printf("  %d) %s\n", i+1, p.provider)
+	}
+	for {
+		answer, err := askWithDefault(editor, "服务商", "1")
+		if err != nil {
+			return providerPreset{}, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(providerPresets) {
+			return providerPresets[n-1], nil
+		}
+		for _, p := range providerPresets {
+			if string(p.provider) == answer {
+				return p, nil
+			}
+		}
+		fmt.Printf("无效的选择: %s\n", answer)
+	}
+}
+
+// askWithDefault 询问一项配置，直接回车时使用默认值
+func askWithDefault(editor *terminal.Editor, label, def string) (string, error) {
+	answer, err := editor.Prompt(fmt.Sprintf("%s [%s]: ", label, def))
+	if err != nil {
+		if errors.Is(err, terminal.ErrInterrupt) || errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("已取消配置")
+		}
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// isPlainSecret 判断密钥是否为明文，而不是环境变量、文件或命令引用
+func isPlainSecret(value string) bool {
+	return value != "" && !strings.Contains(value, "${") &&
+		!strings.HasPrefix(value, "file:") && !strings.HasPrefix(value, "cmd:")
+}
+
+// storeCredential 将密钥保存到加密凭据存储，同名凭据已存在时更换其密钥
+func storeCredential(name, secret string) error {
+	store, err := credential.Unlock(credential.DefaultPath(env.SparrowCliHome))
+	if err != nil {
+		return err
+	}
+	if _, ok := store.Get(name); ok {
+		err = store.Rotate(name, secret)
+	} else {
+		err = store.Add(name, secret)
+	}
+	if err != nil {
+		return err
+	}
+	return store.Save()
+}
+
+// testConnection 向接口发送一条最简单的非流式请求，检查地址、模型与密钥是否可用
+func testConnection(m config.ModelConfig, apiKey string) error {
+	model := m.ToModel()
+	model.ApiKey = apiKey
+	messages := []client.Message{{Role: client.UserRole, Content: "ping"}}
+	_, err := client.New(model, client.WithTimeout(setupTestTimeout)).Chat(context.Background(), messages, 0)
+
+	var statusErr *client.StatusError
+	switch {
+	case err == nil:
+		return nil
+	case !errors.As(err, &statusErr):
+		return fmt.Errorf("无法连接接口: %w", err)
+	case statusErr.StatusCode == http.StatusUnauthorized, statusErr.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("API 密钥无效或没有权限（%s）: %s", statusErr.Status, statusErr.Body)
+	case statusErr.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("接口地址或模型名称有误（%s）: %s", statusErr.Status, statusErr.Body)
+	default:
+		return statusErr
+	}
+}
@@ -0,0 +1,187 @@
+package codeblock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sparrow-cli/file"
+	"sparrow-cli/markdown"
+	"strings"
+	"time"
+)
+
+// DefaultRunTimeout 运行代码块的默认超时时间
+const DefaultRunTimeout = 30 * time.Second
+
+// sandboxEnv 标记子进程为沙箱进程的环境变量，由 InitSandbox 识别
+const sandboxEnv = "SPARROW_CLI_SANDBOX"
+
+// Block 回答中的一个围栏代码块
+type Block struct {
+	Index int    // 从 1 开始的序号
+	Lang  string // 代码块标注的语言，可能为空
+	Code  string // 代码内容，不包含围栏
+}
+
+// runner 某种语言的运行方式
+type runner struct {
+	filename string   // 写入临时目录的文件名
+	command  []string // 执行命令，文件名作为最后一个参数
+}
+
+// runners 支持运行的语言
+var runners = map[string]runner{
+	"sh":         {"main.sh", []string{"sh"}},
+	"shell":      {"main.sh", []string{"sh"}},
+	"bash":       {"main.sh", []string{"bash"}},
+	"zsh":        {"main.sh", []string{"zsh"}},
+	"python":     {"main.py", []string{"python3"}},
+	"py":         {"main.py", []string{"python3"}},
+	"javascript": {"main.js", []string{"node"}},
+	"js":         {"main.js", []string{"node"}},
+	"go":         {"main.go", []string{"go", "run"}},
+	"ruby":       {"main.rb", []string{"ruby"}},
+	"perl":       {"main.pl", []string{"perl"}},
+}
+
+// Extract 提取 Markdown 文本中的所有围栏代码块。
+// param content 为模型回答的完整内容。
+//
+// return 按出现顺序排列的代码块，未闭合的代码块同样会被提取。
+func Extract(content string) []Block {
+	var blocks []Block
+	var current *Block
+	var fence string
+	var code []string
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if current == nil {
+			if m := markdown.FencePattern.FindStringSubmatch(trimmed); m != nil {
+				current = &Block{Index: len(blocks) + 1, Lang: strings.ToLower(m[2])}
+				fence = m[1]
+				code = nil
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			current.Code = strings.Join(code, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+		code = append(code, line)
+	}
+
+	if current != nil {
+		current.Code = strings.Join(code, "\n")
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// Summary 返回代码块的单行摘要，用于列表展示
+func (b Block) Summary() string {
+	lang := b.Lang
+	if lang == "" {
+		lang = "text"
+	}
+	lines := strings.Count(b.Code, "\n") + 1
+	first := strings.TrimSpace(strings.SplitN(b.Code, "\n", 2)[0])
+	if len([]rune(first)) > 50 {
+		first = string([]rune(first)[:50]) + "..."
+	}
+	return fmt.Sprintf("[%d] %s, %d 行: %s", b.Index, lang, lines, first)
+}
+
+// Runnable 判断代码块的语言是否支持运行
+func (b Block) Runnable() bool {
+	_, ok := runners[b.Lang]
+	return ok
+}
+
+// Save 将代码块写入新文件，文件已存在时返回错误以免覆盖。
+// param path 为目标文件路径，必须包含扩展名。
+//
+// return 可能的错误。如果写入失败则返回错误。
+func (b Block) Save(path string) error {
+	content := b.Code
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return file.WriteNewFile(path, []byte(content))
+}
+
+// Run 在沙箱中运行代码块，运行结束后删除临时目录。
+// 沙箱没有网络，除临时目录外的文件系统均为只读，并限制 CPU、内存、文件大小与进程数；
+// 子进程的工作目录与 HOME 均为临时目录，仅继承 PATH 等少量环境变量，并受超时限制。
+// 当前平台无法隔离时拒绝运行。
+// param ctx 为运行上下文，取消后子进程会被终止。
+// param stdout、stderr 为子进程的输出目标。
+//
+// return 可能的错误。语言不支持、启动失败或退出码非零时返回错误。
+func (b Block) Run(ctx context.Context, stdout, stderr io.Writer) error {
+	r, ok := runners[b.Lang]
+	if !ok {
+		return fmt.Errorf("不支持运行 %q 语言的代码块", b.Lang)
+	}
+	interpreter, err := exec.LookPath(r.command[0])
+	if err != nil {
+		return fmt.Errorf("未找到解释器 %s: %w", r.command[0], err)
+	}
+
+	dir, err := os.MkdirTemp("", "sparrow-cli-run-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer func() {
+		_ = file.ForceRemove(dir)
+	}()
+
+	script := filepath.Join(dir, r.filename)
+	if err := os.WriteFile(script, []byte(b.Code), 0600); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRunTimeout)
+		defer cancel()
+	}
+
+	command := append(append([]string{interpreter}, r.command[1:]...), r.filename)
+	cmd, err := sandboxCommand(ctx, dir, command)
+	if err != nil {
+		return err
+	}
+	cmd.Dir = dir
+	cmd.Env = append(runEnv(dir), sandboxEnv+"=1")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("运行超时")
+		}
+		return fmt.Errorf("运行失败: %w", err)
+	}
+	return nil
+}
+
+// runEnv 构造子进程的最小环境变量集合
+func runEnv(dir string) []string {
+	env := []string{"HOME=" + dir, "TMPDIR=" + dir}
+	for _, key := range []string{"PATH", "LANG", "LC_ALL", "TERM", "GOPATH", "GOMODCACHE"} {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	// 沙箱中只有临时目录可写，go run 的构建缓存也放在其中，且不能联网下载工具链
+	return append(env, "GOCACHE="+filepath.Join(dir, ".cache", "go-build"), "GOTOOLCHAIN=local")
+}
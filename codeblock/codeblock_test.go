@@ -0,0 +1,103 @@
+package codeblock
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	InitSandbox()
+	os.Exit(m.Run())
+}
+
+const answer = "示例：\n" +
+	"```go\n" +
+	"package main\n" +
+	"```\n" +
+	"然后运行：\n" +
+	"~~~sh\n" +
+	"echo hello\n" +
+	"pwd\n" +
+	"~~~\n" +
+	"```\n" +
+	"未闭合"
+
+func TestExtract(t *testing.T) {
+	blocks := Extract(answer)
+	if len(blocks) != 3 {
+		t.Fatalf("Extract() got %d blocks, want 3", len(blocks))
+	}
+	if blocks[0].Lang != "go" || blocks[0].Code != "package main" {
+		t.Errorf("blocks[0] = %+v", blocks[0])
+	}
+	if blocks[1].Lang != "sh" || blocks[1].Code != "echo hello\npwd" {
+		t.Errorf("blocks[1] = %+v", blocks[1])
+	}
+	if blocks[2].Index != 3 || blocks[2].Code != "未闭合" {
+		t.Errorf("blocks[2] = %+v", blocks[2])
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	b := Block{Index: 1, Lang: "go", Code: "package main"}
+	if err := b.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "package main\n" {
+		t.Errorf("saved content = %q", data)
+	}
+	if err := b.Save(path); err == nil {
+		t.Errorf("Save() should refuse to overwrite an existing file")
+	}
+}
+
+func TestRun(t *testing.T) {
+	skipWithoutSandbox(t)
+	var stdout bytes.Buffer
+	b := Block{Index: 1, Lang: "sh", Code: "echo hello; touch created.txt; ls"}
+	if err := b.Run(context.Background(), &stdout, &stdout); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := stdout.String(); got != "hello\ncreated.txt\nmain.sh\n" {
+		t.Errorf("Run() output = %q", got)
+	}
+}
+
+func TestRunIsolation(t *testing.T) {
+	skipWithoutSandbox(t)
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	code := "touch " + outside + " 2>/dev/null && echo writable\n" +
+		"tail -n +3 /proc/net/dev | cut -d: -f1 | tr -d ' '\n" +
+		"ulimit -f\n"
+	var stdout bytes.Buffer
+	b := Block{Index: 1, Lang: "sh", Code: code}
+	if err := b.Run(context.Background(), &stdout, &stdout); err != nil {
+		t.Fatalf("Run() error = %v, output = %q", err, stdout.String())
+	}
+	if _, err := os.Stat(outside); err == nil {
+		t.Errorf("Run() wrote outside the temporary directory")
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 || lines[0] != "lo" || lines[1] == "unlimited" {
+		t.Errorf("Run() output = %q, want only the loopback interface and a file size limit", stdout.String())
+	}
+}
+
+// skipWithoutSandbox 没有 sh 或当前平台不支持沙箱时跳过测试
+func skipWithoutSandbox(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("sandbox is only supported on linux")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+}
@@ -0,0 +1,128 @@
+//go:build linux
+
+package codeblock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxID 沙箱内运行代码的用户与组 ID，映射到当前用户，不为 0 以便 exec 时丢弃全部 capability
+const sandboxID = 1000
+
+// sandboxLimits 沙箱进程的资源限制
+var sandboxLimits = []struct {
+	resource int
+	value    uint64
+}{
+	{unix.RLIMIT_CPU, uint64(2 * DefaultRunTimeout.Seconds())},
+	{unix.RLIMIT_AS, 4 << 30},
+	{unix.RLIMIT_FSIZE, 64 << 20},
+	{unix.RLIMIT_NOFILE, 1024},
+	{unix.RLIMIT_NPROC, 256},
+	{unix.RLIMIT_CORE, 0},
+}
+
+// sandboxCommand 构造在沙箱中运行 command 的子进程。
+// 子进程重新执行当前程序，在新的 user、mount、network、pid、ipc 与 uts 命名空间中由 InitSandbox 完成隔离后再执行解释器。
+// param dir 为临时目录，是沙箱中唯一可写的位置。
+// param command 为解释器及其参数。
+//
+// return 子进程与可能的错误。无法定位当前程序时返回错误。
+func sandboxCommand(ctx context.Context, dir string, command []string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("定位当前程序失败: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, self)
+	cmd.Args = append([]string{"sparrow-cli-sandbox", dir}, command...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET |
+			syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: sandboxID, HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: sandboxID, HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Credential:                 &syscall.Credential{Uid: sandboxID, Gid: sandboxID, NoSetGroups: true},
+		AmbientCaps:                []uintptr{unix.CAP_SYS_ADMIN},
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+	return cmd, nil
+}
+
+// InitSandbox 如果当前进程是 sandboxCommand 启动的沙箱进程，则完成隔离并执行解释器，不再返回。
+// 必须在 main 的最开始调用，普通进程调用时直接返回。
+func InitSandbox() {
+	if os.Getenv(sandboxEnv) != "1" {
+		return
+	}
+	if err := enterSandbox(); err != nil {
+		fmt.Fprintf(os.Stderr, "沙箱: %v\n", err)
+		os.Exit(125)
+	}
+}
+
+// enterSandbox 将根目录以下的挂载点设为只读，仅保留临时目录可写，设置资源限制后执行解释器
+func enterSandbox() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("参数不完整")
+	}
+	dir, command := os.Args[1], os.Args[2:]
+
+	// capability 与 ambient 集合按线程生效，隔离与 exec 必须在同一线程上完成
+	runtime.LockOSThread()
+
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("设置挂载传播失败: %w", err)
+	}
+	if err := unix.Mount(dir, dir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("绑定临时目录失败: %w", err)
+	}
+	readOnly := &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}
+	if err := unix.MountSetattr(unix.AT_FDCWD, "/", unix.AT_RECURSIVE, readOnly); err != nil {
+		return fmt.Errorf("将根目录设为只读失败: %w", err)
+	}
+	writable := &unix.MountAttr{Attr_clr: unix.MOUNT_ATTR_RDONLY}
+	if err := unix.MountSetattr(unix.AT_FDCWD, dir, unix.AT_RECURSIVE, writable); err != nil {
+		return fmt.Errorf("将临时目录设为可写失败: %w", err)
+	}
+	// 重新挂载 /proc 以只显示沙箱中的进程与网络
+	if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("挂载 /proc 失败: %w", err)
+	}
+
+	for _, limit := range sandboxLimits {
+		rlimit := &unix.Rlimit{Cur: limit.value, Max: limit.value}
+		if err := unix.Setrlimit(limit.resource, rlimit); err != nil {
+			return fmt.Errorf("设置资源限制失败: %w", err)
+		}
+	}
+
+	// 沙箱内的用户不是 0，清空 ambient 集合后 exec 会丢弃全部 capability，代码无法撤销上面的挂载
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("清除 capability 失败: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("设置 no_new_privs 失败: %w", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("进入临时目录失败: %w", err)
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, sandboxEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	if err := unix.Exec(command[0], command, env); err != nil {
+		return fmt.Errorf("执行 %s 失败: %w", command[0], err)
+	}
+	return nil
+}
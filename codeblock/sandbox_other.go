@@ -0,0 +1,17 @@
+//go:build !linux
+
+package codeblock
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// sandboxCommand 非 Linux 平台没有可用的隔离手段，拒绝运行代码块
+func sandboxCommand(ctx context.Context, dir string, command []string) (*exec.Cmd, error) {
+	return nil, errors.New("当前平台不支持沙箱，无法运行代码块")
+}
+
+// InitSandbox 非 Linux 平台不会启动沙箱进程，直接返回
+func InitSandbox() {}
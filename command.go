@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sparrow-cli/prompt"
+	"strings"
+)
+
+// command 对话中以 / 开头的命令
+type command struct {
+	name    string                                // 命令名称，不含 /
+	usage   string                                // 参数说明
+	desc    string                                // 命令描述
+	handler func(s *session, args []string) error // 命令处理函数
+}
+
+// commands 已注册的命令
+var commands = make(map[string]*command)
+
+// registerCommand 注册对话命令，各命令文件在 init 中调用
+func registerCommand(cmd *command) {
+	commands[cmd.name] = cmd
+}
+
+func init() {
+	registerCommand(&command{
+		name: "help",
+		desc: "列出所有可用命令",
+		handler: func(s *session, args []string) error {
+			names := make([]string, 0, len(commands))
+			for name := range commands {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			fmt.Println("可用命令：")
+			for _, name := range names {
+				cmd := commands[name]
+				fmt.Printf("  /%-24s %s\n", strings.TrimSpace(cmd.name+" "+cmd.usage), cmd.desc)
+			}
+			fmt.Printf("  %-25s %s\n", "!quit", "退出对话")
+
+			if templates := s.templates.List(); len(templates) > 0 {
+				fmt.Printf("提示词模板（%s）：\n", s.templates.Dir())
+				for _, p := range templates {
+					fmt.Printf("  /%-24s %s\n", p.Name+" [参数]", p.Description)
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// handleCommand 解析并执行一条对话命令
+func (s *session) handleCommand(line string) {
+	fields := strings.Fields(strings.TrimPrefix(line, "/"))
+	if len(fields) == 0 {
+		return
+	}
+
+	cmd, ok := commands[fields[0]]
+	if !ok {
+		// 内置命令优先，其次查找同名的提示词模板
+		if p, found := s.templates.Get(fields[0]); found {
+			s.runTemplate(p, fields[1:])
+			return
+		}
+		fmt.Printf("未知命令: /%s，输入 /help 查看可用命令\n", fields[0])
+		return
+	}
+	if err := cmd.handler(s, fields[1:]); err != nil {
+		fmt.Printf("/%s 执行失败: %v\n", cmd.name, err)
+	}
+}
+
+// runTemplate 询问模板变量并渲染模板，将结果作为问题发送，命令参数可通过 {{.Args}} 引用
+func (s *session) runTemplate(p *prompt.Prompt, args []string) {
+	vars := s.askVars(p)
+	vars["Args"] = strings.Join(args, " ")
+
+	text, err := p.Render(vars)
+	if err != nil {
+		fmt.Printf("/%s 执行失败: %v\n", p.Name, err)
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		fmt.Printf("/%s 渲染结果为空\n", p.Name)
+		return
+	}
+	s.ask(text)
+}
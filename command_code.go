@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sparrow-cli/codeblock"
+	"sparrow-cli/config"
+	"sparrow-cli/terminal"
+	"strconv"
+)
+
+func init() {
+	registerCommand(&command{
+		name:    "blocks",
+		desc:    "列出最近一次回答中的代码块",
+		handler: listBlocks,
+	})
+	registerCommand(&command{
+		name:    "copy",
+		usage:   "<序号>",
+		desc:    "通过 OSC 52 将代码块复制到剪贴板（SSH 下同样可用）",
+		handler: copyBlock,
+	})
+	registerCommand(&command{
+		name:    "save",
+		usage:   "<序号> <文件>",
+		desc:    "将代码块写入新文件",
+		handler: saveBlock,
+	})
+	registerCommand(&command{
+		name:    "run",
+		usage:   "<序号>",
+		desc:    "在无网络、只有临时目录可写的沙箱中运行代码块",
+		handler: runBlock,
+	})
+}
+
+// listBlocks 列出最近一次回答中的代码块
+func listBlocks(s *session, args []string) error {
+	blocks := codeblock.Extract(s.lastAnswer)
+	if len(blocks) == 0 {
+		fmt.Println("最近一次回答中没有代码块")
+		return nil
+	}
+	for _, b := range blocks {
+		fmt.Println(b.Summary())
+	}
+	return nil
+}
+
+// copyBlock 将代码块复制到剪贴板
+func copyBlock(s *session, args []string) error {
+	b, err := pickBlock(s, args)
+	if err != nil {
+		return err
+	}
+	if ok, err := checkPermission(s.editor, s.tools().Clipboard.Or(config.DefaultTools.Clipboard), fmt.Sprintf("确认复制代码块 [%d]？", b.Index)); !ok {
+		return err
+	}
+	if err := terminal.CopyToClipboard(os.Stdout, b.Code); err != nil {
+		return err
+	}
+	fmt.Printf("已复制代码块 [%d]\n", b.Index)
+	return nil
+}
+
+// saveBlock 将代码块写入文件
+func saveBlock(s *session, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("用法: /save <序号> <文件>")
+	}
+	b, err := pickBlock(s, args)
+	if err != nil {
+		return err
+	}
+	if ok, err := checkPermission(s.editor, s.tools().WriteFile.Or(config.DefaultTools.WriteFile), fmt.Sprintf("确认将代码块 [%d] 写入 %s？", b.Index, args[1])); !ok {
+		return err
+	}
+	if err := b.Save(args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("已将代码块 [%d] 写入 %s\n", b.Index, args[1])
+	return nil
+}
+
+// runBlock 确认后在临时目录中运行代码块
+func runBlock(s *session, args []string) error {
+	b, err := pickBlock(s, args)
+	if err != nil {
+		return err
+	}
+	if !b.Runnable() {
+		return fmt.Errorf("不支持运行 %q 语言的代码块", b.Lang)
+	}
+
+	permission := s.tools().RunCode.Or(config.DefaultTools.RunCode)
+	if permission == config.PermissionAsk {
+		fmt.Println(b.Code)
+	}
+	if ok, err := checkPermission(s.editor, permission, fmt.Sprintf("确认在沙箱中运行代码块 [%d]？", b.Index)); !ok {
+		return err
+	}
+
+	// 运行期间 Ctrl-C 只终止子进程
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := b.Run(ctx, os.Stdout, os.Stderr); err != nil {
+		return err
+	}
+	fmt.Printf("代码块 [%d] 运行完成\n", b.Index)
+	return nil
+}
+
+// checkPermission 按配置的工具权限决定是否继续，权限为 ask 时询问用户，editor 为 nil 表示无法询问
+func checkPermission(editor *terminal.Editor, permission config.Permission, question string) (bool, error) {
+	switch permission {
+	case config.PermissionAllow:
+		return true, nil
+	case config.PermissionDeny:
+		return false, fmt.Errorf("该操作已被配置禁止")
+	default:
+		if editor == nil {
+			return false, fmt.Errorf("该操作需要确认，但标准输入不是终端，无法询问")
+		}
+		if !editor.Confirm(question) {
+			fmt.Println("已取消")
+			return false, nil
+		}
+		return true, nil
+	}
+}
+
+// pickBlock 根据第一个参数选择代码块
+func pickBlock(s *session, args []string) (codeblock.Block, error) {
+	if len(args) == 0 {
+		return codeblock.Block{}, fmt.Errorf("请指定代码块序号，可通过 /blocks 查看")
+	}
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return codeblock.Block{}, fmt.Errorf("无效的代码块序号: %s", args[0])
+	}
+
+	blocks := codeblock.Extract(s.lastAnswer)
+	if index < 1 || index > len(blocks) {
+		return codeblock.Block{}, fmt.Errorf("代码块 [%d] 不存在，共有 %d 个代码块", index, len(blocks))
+	}
+	return blocks[index-1], nil
+}
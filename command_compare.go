@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sparrow-cli/client"
+	"sparrow-cli/global"
+	"sparrow-cli/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	registerCommand(&command{
+		name:    "compare",
+		usage:   "<模型1,模型2,...|all> <问题>",
+		desc:    "同时向多个模型提问并对比回答、延迟、Token 与费用，结果不加入对话历史",
+		handler: compareModels,
+	})
+}
+
+// compareStream 对比模式中一个模型的回答，请求在后台进行，输出按模型顺序依次进行
+type compareStream struct {
+	model *global.Model
+
+	mu         sync.Mutex
+	cond       *sync.Cond
+	pending    []string             // 已收到但尚未输出的回答片段
+	done       bool                 // 请求是否已结束
+	response   *client.ResponseBody // 完整的响应，请求失败时为 nil
+	err        error                // 请求失败的原因
+	firstToken time.Duration        // 收到第一个回答片段的耗时
+	elapsed    time.Duration        // 请求的总耗时
+}
+
+// newCompareStream 创建模型的回答流
+func newCompareStream(model *global.Model) *compareStream {
+	c := &compareStream{model: model}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// compareModels 并发向多个模型发送同一个问题，按模型顺序分段输出回答并汇总对比结果
+func compareModels(s *session, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("用法: /compare <模型1,模型2,...|all> <问题>")
+	}
+	models, err := s.compareTargets(args[0])
+	if err != nil {
+		return err
+	}
+
+	message, _, ok := s.userMessage(strings.Join(args[1:], " "))
+	if !ok {
+		return nil
+	}
+	messages := append(append([]client.Message(nil), s.messages...), message)
+
+	// 对比期间 Ctrl-C 取消所有请求
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	streams := make([]*compareStream, len(models))
+	for i, model := range models {
+		streams[i] = newCompareStream(model)
+		if message.HasImages() && !client.SupportsImages(model.Provider) {
+			streams[i].finish(nil, fmt.Errorf("服务商 %s 不支持图片输入", model.Provider))
+			continue
+		}
+		go streams[i].run(ctx, client.New(model), messages, s.temperature)
+	}
+
+	for i, c := range streams {
+		fmt.Printf("\n── [%d/%d] %s ──\n", i+1, len(streams), c.model.Name)
+		last := ""
+		for {
+			content, ok := c.next()
+			if !ok {
+				break
+			}
+			last = content
+			if _, err := s.renderer.WriteString(content); err != nil {
+				logger.Warn("输出回答失败: %v", err)
+			}
+		}
+		if err := s.renderer.Flush(); err != nil {
+			logger.Warn("输出回答失败: %v", err)
+		}
+		if last != "" && !strings.HasSuffix(last, "\n") {
+			fmt.Println()
+		}
+		if c.err != nil {
+			fmt.Printf("✗ %v\n", c.err)
+		} else if c.response.Filtered() {
+			fmt.Println("⚠ 回答触发了内容过滤，已被截断")
+		}
+	}
+
+	fmt.Println()
+	if _, err := s.renderer.WriteString(compareSummary(streams)); err != nil {
+		logger.Warn("输出对比结果失败: %v", err)
+	}
+	return s.renderer.Flush()
+}
+
+// compareTargets 解析要对比的模型列表，all 表示配置中的全部模型，地址由项目配置设置的模型需要明确列出
+func (s *session) compareTargets(arg string) ([]*global.Model, error) {
+	var names []string
+	if arg == "all" {
+		for _, m := range s.config.Models {
+			if !s.config.ProjectEndpoint(m.Model) {
+				names = append(names, m.Model)
+			}
+		}
+	} else {
+		for _, name := range strings.Split(arg, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) < 2 {
+		return nil, fmt.Errorf("至少需要指定两个模型，多个模型用逗号分隔")
+	}
+
+	models := make([]*global.Model, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		m, err := s.config.Model(name)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+	return models, nil
+}
+
+// run 发送请求并接收流式回答，由后台协程执行
+func (c *compareStream) run(ctx context.Context, chat *client.Client, messages []client.Message, temperature float64) {
+	start := time.Now()
+	responseBody, err := chat.ChatStream(ctx, messages, temperature, func(content string, isFinished bool) {
+		if content != "" {
+			c.write(content, time.Since(start))
+		}
+	})
+	c.finishAfter(start, responseBody, err)
+}
+
+// write 追加一个回答片段
+func (c *compareStream) write(content string, since time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.firstToken == 0 {
+		c.firstToken = since
+	}
+	c.pending = append(c.pending, content)
+	c.cond.Signal()
+}
+
+// finishAfter 记录总耗时并结束回答流
+func (c *compareStream) finishAfter(start time.Time, response *client.ResponseBody, err error) {
+	c.mu.Lock()
+	c.elapsed = time.Since(start)
+	c.mu.Unlock()
+	c.finish(response, err)
+}
+
+// finish 结束回答流
+func (c *compareStream) finish(response *client.ResponseBody, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.response = response
+	c.err = err
+	c.done = true
+	c.cond.Signal()
+}
+
+// next 等待并取出已收到的回答片段，回答流结束且片段已全部取出时返回 false
+func (c *compareStream) next() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.pending) == 0 && !c.done {
+		c.cond.Wait()
+	}
+	if len(c.pending) == 0 {
+		return "", false
+	}
+	content := strings.Join(c.pending, "")
+	c.pending = c.pending[:0]
+	return content, true
+}
+
+// compareSummary 以 Markdown 表格汇总各模型的延迟、Token 与费用
+func compareSummary(streams []*compareStream) string {
+	var sb strings.Builder
+	sb.WriteString("| 模型 | 首字延迟 | 总耗时 | 输入 Token | 输出 Token | 费用 |\n")
+	sb.WriteString("| --- | ---: | ---: | ---: | ---: | ---: |\n")
+	for _, c := range streams {
+		if c.err != nil {
+			fmt.Fprintf(&sb, "| %s | 失败 | %s | - | - | - |\n", c.model.Name, formatDuration(c.elapsed))
+			continue
+		}
+		usage := c.response.Usage
+		if usage == (client.Usage{}) {
+			// 服务商没有在流式响应中返回 Token 使用情况
+			fmt.Fprintf(&sb, "| %s | %s | %s | 不可用 | 不可用 | 不可用 |\n", c.model.Name,
+				formatDuration(c.firstToken), formatDuration(c.elapsed))
+			continue
+		}
+		cost := "-"
+		if v, ok := c.model.Cost(usage.PromptTokens, usage.CompletionTokens); ok {
+			cost = fmt.Sprintf("%s%.6f", c.model.Currency, v)
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %d | %d | %s |\n", c.model.Name,
+			formatDuration(c.firstToken), formatDuration(c.elapsed), usage.PromptTokens, usage.CompletionTokens, cost)
+	}
+	return sb.String()
+}
+
+// formatDuration 以秒为单位输出耗时，未记录时输出 -
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sparrow-cli/client"
+	"sparrow-cli/schema"
+	"strings"
+)
+
+func init() {
+	registerCommand(&command{
+		name:    "json",
+		usage:   "<Schema文件> [-o 文件] <问题>",
+		desc:    "要求当前模型输出符合 JSON Schema 的 JSON 并在本地校验，结果不加入对话历史",
+		handler: askJSONCommand,
+	})
+}
+
+// askJSONCommand 带着当前对话向当前模型请求符合 Schema 的 JSON，问题中可以用 @ 引用文件
+func askJSONCommand(s *session, args []string) error {
+	usage := fmt.Errorf("用法: /json <Schema文件> [-o 文件] <问题>")
+	if len(args) < 2 {
+		return usage
+	}
+	sc, err := schema.Load(args[0])
+	if err != nil {
+		return err
+	}
+	args = args[1:]
+	output := ""
+	if args[0] == "-o" {
+		if len(args) < 3 {
+			return usage
+		}
+		output, args = args[1], args[2:]
+	}
+
+	model, err := s.config.Model(currentModelName())
+	if err != nil {
+		return err
+	}
+	if ok, err := confirmOutput(s.editor, s.tools(), output); !ok {
+		return err
+	}
+	message, _, ok := s.userMessage(strings.Join(args, " "))
+	if !ok {
+		return nil
+	}
+	if message.HasImages() && !client.SupportsImages(model.Provider) {
+		return fmt.Errorf("服务商 %s 不支持图片输入", model.Provider)
+	}
+
+	// 请求期间 Ctrl-C 取消请求
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return askJSON(ctx, model, client.JSONRequest{
+		Messages:    append(append([]client.Message(nil), s.messages...), message),
+		Temperature: s.temperature,
+		Schema:      sc,
+		Retries:     defaultJSONRetries,
+	}, output)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+func init() {
+	registerCommand(&command{
+		name:    "models",
+		usage:   "[refresh] [关键词]",
+		desc:    "查询已配置服务提供的模型列表，并可将其中的模型添加到配置",
+		handler: listModels,
+	})
+}
+
+// listModels 列出各服务提供的模型并询问要添加到配置的模型，refresh 忽略缓存重新查询
+func listModels(s *session, args []string) error {
+	refresh := len(args) > 0 && args[0] == "refresh"
+	if refresh {
+		args = args[1:]
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	endpoints := modelEndpoints(s.config)
+	fetchEndpoints(ctx, endpoints, refresh)
+	stop()
+
+	if discovered := printEndpoints(endpoints, strings.Join(args, " ")); len(discovered) > 0 {
+		offerModels(s.editor, discovered)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"sparrow-cli/config"
+)
+
+func init() {
+	registerCommand(&command{
+		name:    "profile",
+		usage:   "[名称]",
+		desc:    "切换配置档案（模型、系统提示词、温度与工具权限），不带参数时列出所有档案",
+		handler: switchProfile,
+	})
+}
+
+// switchProfile 列出配置档案或切换到指定档案
+func switchProfile(s *session, args []string) error {
+	c := config.Current()
+	if len(args) == 0 {
+		names := c.ProfileNames()
+		if len(names) == 0 {
+			fmt.Println("配置中没有定义配置档案（profiles）")
+			return nil
+		}
+		for _, name := range names {
+			mark := " "
+			if s.profile != nil && s.profile.Name == name {
+				mark = "*"
+			}
+			p := c.Profiles[name]
+			desc := p.Description
+			if p.Extends != "" {
+				desc += "（继承 " + p.Extends + "）"
+			}
+			fmt.Printf(" %s %-16s %s\n", mark, name, desc)
+		}
+		return nil
+	}
+
+	profile, err := c.Profile(args[0])
+	if err != nil {
+		return err
+	}
+	if profile.Prompt != "" {
+		p, ok := s.library.Get(profile.Prompt)
+		if !ok {
+			return fmt.Errorf("配置档案 %s 引用的提示词 %s 不存在", profile.Name, profile.Prompt)
+		}
+		s.usePrompt(p)
+	}
+	s.applyProfile(&profile)
+	fmt.Printf("✓ 已切换到配置档案 %s（模型: %s，提示词: %s，温度: %.2g）\n", profile.Name, currentModelName(), s.sysPrompt.Name, s.temperature)
+	return nil
+}
+
+// applyProfile 应用配置档案中的模型、温度与工具权限，系统提示词由调用方切换
+func (s *session) applyProfile(p *config.ProfileConfig) {
+	if p.Model != "" {
+		if err := config.UseModel(p.Model); err != nil {
+			fmt.Printf("警告: %v，继续使用当前模型\n", err)
+		}
+	}
+	if p.Temperature != nil {
+		s.temperature = *p.Temperature
+	}
+	s.profile = p
+}
+
+// tools 返回当前生效的工具权限，配置档案中的权限覆盖全局配置
+func (s *session) tools() config.ToolsConfigData {
+	tools := config.Current().Tools
+	if s.profile != nil {
+		tools = tools.Merge(s.profile.Tools)
+	}
+	return tools
+}
@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+func init() {
+	registerCommand(&command{
+		name:    "system",
+		usage:   "[名称]",
+		desc:    "切换系统提示词，不带参数时列出提示词库",
+		handler: switchSystemPrompt,
+	})
+}
+
+// switchSystemPrompt 列出提示词库或切换系统提示词
+func switchSystemPrompt(s *session, args []string) error {
+	if len(args) == 0 {
+		fmt.Printf("提示词库: %s\n", s.library.Dir())
+		for _, p := range s.library.List() {
+			mark := " "
+			if p.Name == s.sysPrompt.Name {
+				mark = "*"
+			}
+			fmt.Printf(" %s %-16s %s\n", mark, p.Name, p.Description)
+		}
+		return nil
+	}
+
+	p, ok := s.library.Get(args[0])
+	if !ok {
+		return fmt.Errorf("提示词 %s 不存在", args[0])
+	}
+	s.usePrompt(p)
+	fmt.Printf("✓ 已切换到系统提示词 %s（模型: %s，温度: %.2g）\n", p.Name, currentModelName(), s.temperature)
+	return nil
+}
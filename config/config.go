@@ -4,68 +4,196 @@ import (
 	"fmt"
 	"os"
 	"sparrow-cli/env"
-	"sparrow-cli/file"
 	"sparrow-cli/global"
 	"sync"
+	"sync/atomic"
 
 	"gopkg.in/yaml.v3"
 )
 
-var loadConfigOnce sync.Once
+// Config 一次加载得到的配置快照，加载完成后不再修改，可以在多个 goroutine 中安全读取。
+// 配置重新加载时会整体替换为新的快照，使用方通过 Current 获取最新配置。
+type Config struct {
+	Models        []ModelConfig
+	DefaultPrompt string
+	Logger        LoggerConfigData
+	History       HistoryConfigData
+	Attach        AttachConfigData
+	Tools         ToolsConfigData
+	Profiles      map[string]ProfileConfig
+	Sources       []string   // 参与合并的配置文件，按优先级从低到高排列
+	effective     *yaml.Node // 合并后的配置节点，叶子节点的行尾注释记录了取值来源
+
+	projectEndpoints map[string]bool // 地址、请求头或传输设置由项目配置设置的模型
+}
 
 var (
-	Models []ModelConfig
-	Logger LoggerConfigData
+	// current 当前生效的配置快照
+	current atomic.Pointer[Config]
+	// loadLock 保证同一时间只有一次加载，凭据存储的缓存也由它保护
+	loadLock sync.Mutex
 )
 
-func LoadConfig() {
-	loadConfigOnce.Do(func() {
-		// 2. 判断 SparrowCliHome 是否有 config.yaml 文件
-		// 	2.1 若有，则加载该文件
-		// 	2.2 若没有，则按照 config.items 结构创建 config.yaml 文件并保存在 HOME_PATH 中
-		configFilePath := env.SparrowCliHome + "/config/sparrow_cli_config.yaml"
-		if !file.IsExist(configFilePath) {
-			// 新建文件并保存空配置
-			f, createErr := file.CreateFile(configFilePath)
-			if createErr != nil {
-				panic(createErr)
-			}
-			pc := &ProjectConfig{}
-			yamlData, err := yaml.Marshal(pc)
-			if err != nil {
-				panic(fmt.Errorf("将配置数据转换为 YAML 失败: %w", err))
-			}
-			_, wErr := f.Write(yamlData)
-			if wErr != nil {
-				panic(fmt.Errorf("写入 YAML 数据到文件失败: %w", wErr))
-			}
-			closeErr := f.Close()
-			if closeErr != nil {
-				panic(fmt.Errorf("关闭文件失败: %w", closeErr))
+// HomeConfigPath 返回家目录配置文件路径
+func HomeConfigPath() string {
+	return env.SparrowCliHome + "/config/sparrow_cli_config.yaml"
+}
+
+// Paths 返回参与合并的配置文件：家目录配置与当前目录向上找到的项目级配置，按优先级从低到高排列
+func Paths() []string {
+	paths := []string{HomeConfigPath()}
+	if cwd, err := os.Getwd(); err == nil {
+		paths = append(paths, FindProjectConfigs(cwd)...)
+	}
+	return paths
+}
+
+// Current 返回当前生效的配置，尚未加载时返回空配置
+func Current() *Config {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	return &Config{}
+}
+
+// LoadConfig 首次加载并校验配置，并将默认模型（见 DefaultModel）设为当前模型。已成功加载过时直接返回。
+//
+// return 可能的错误。配置无效时返回 *ValidationError，包含全部问题及其行号。
+func LoadConfig() error {
+	if current.Load() != nil {
+		return nil
+	}
+
+	// 升级旧版本的家目录配置，项目级配置可能由团队共享，只在内存中迁移并提示
+	if result, err := Migrate(HomeConfigPath()); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 迁移配置文件失败: %v\n", err)
+	} else if result != nil {
+		fmt.Fprintln(os.Stderr, result)
+	}
+	for _, path := range Paths()[1:] {
+		if version, err := FileVersion(path); err == nil && version < CurrentVersion {
+			fmt.Fprintf(os.Stderr, "提示: 项目配置 %s 的版本为 %d，可在该目录运行 sparrow-cli config migrate --project 升级\n", path, version)
+		}
+	}
+
+	c, err := Reload()
+	if err != nil {
+		return err
+	}
+
+	// 设置环境中的默认模型，地址由项目配置设置的模型只有明确选择时才使用
+	if m, ok := c.DefaultModel(); ok {
+		global.SetCurrentModel(m.ToModel())
+	}
+	return nil
+}
+
+// Reload 重新读取并校验配置文件，成功后原子地替换当前配置，失败时保留原有配置。
+// 不会修改当前模型，由调用方决定如何应用新配置。
+//
+// return 新的配置和可能的错误。
+func Reload() (*Config, error) {
+	loadLock.Lock()
+	defer loadLock.Unlock()
+
+	c, err := load()
+	if err != nil {
+		return nil, err
+	}
+	current.Store(c)
+	return c, nil
+}
+
+// load 实际的加载逻辑
+func load() (*Config, error) {
+	// 家目录配置文件不存在时按空配置处理，首次启动由配置向导生成
+	// 查找项目级配置文件并校验，校验通过后与家目录配置按优先级合并
+	paths := Paths()
+	if problems := Validate(paths); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	merged, mergeErr := mergeLayers(paths)
+	if mergeErr != nil {
+		return nil, mergeErr
+	}
+
+	// 解析配置数据
+	conf := &ProjectConfig{}
+	if unErr := merged.Decode(conf); unErr != nil {
+		// 如果解析配置到结构体时发生错误，返回错误信息
+		return nil, fmt.Errorf("reflect config to struct error: %w", unErr)
+	}
+
+	// 记录会解析引用的字段来自哪个配置文件，只解析家目录配置中的引用
+	if models := child(merged, "models"); models != nil {
+		for i, item := range models.Content {
+			if i < len(conf.Models) {
+				conf.Models[i].sources = fieldSources(item)
 			}
 		}
+	}
+
+	// 解析模型配置中的环境变量与密钥引用，解析失败的模型仍可加载，请求时才会失败
+	for _, err := range append(resolveModels(conf.Models), resolveCredentials(conf.Models)...) {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+	}
+
+	return &Config{
+		Models:        conf.Models,
+		DefaultPrompt: conf.DefaultPrompt,
+		Logger:        conf.Logger,
+		History:       conf.History,
+		Attach:        conf.Attach,
+		Tools:         conf.Tools,
+		Profiles:      conf.Profiles,
+		Sources:       paths,
+		effective:     merged,
+
+		projectEndpoints: projectEndpointModels(merged),
+	}, nil
+}
+
+// ProjectEndpoint 判断模型的地址、请求头或传输设置是否由项目配置设置。
+// 这类模型会把提问发往项目指定的地址，不会成为默认模型或自动切换到的备用模型，只有明确选择时才使用
+func (c *Config) ProjectEndpoint(name string) bool {
+	return c.projectEndpoints[name]
+}
 
-		// 3. 从配置文件中加载配置
-		// 读取配置文件内容
-		data, readErr := os.ReadFile(configFilePath)
-		if readErr != nil {
-			// 如果读取配置文件时发生错误，返回错误信息
-			panic(fmt.Errorf("load config file error: %w", readErr))
+// DefaultModel 返回默认模型：第一个地址不由项目配置设置的模型，没有这样的模型时返回 false
+func (c *Config) DefaultModel() (ModelConfig, bool) {
+	for _, m := range c.Models {
+		if !c.ProjectEndpoint(m.Model) {
+			return m, true
 		}
-		// 解析配置数据
-		conf := &ProjectConfig{}
-		if unErr := yaml.Unmarshal(data, &conf); unErr != nil {
-			// 如果解析配置到结构体时发生错误，返回错误信息
-			panic(fmt.Errorf("reflect config to struct error: %w", unErr))
+	}
+	return ModelConfig{}, false
+}
+
+// FindModel 按名称查找模型配置
+func (c *Config) FindModel(name string) (ModelConfig, bool) {
+	for _, m := range c.Models {
+		if m.Model == name {
+			return m, true
 		}
+	}
+	return ModelConfig{}, false
+}
 
-		// 设置全局配置
-		Models = conf.Models
-		Logger = conf.Logger
+// Model 按名称查找模型，返回发送请求使用的模型
+func (c *Config) Model(name string) (*global.Model, error) {
+	m, ok := c.FindModel(name)
+	if !ok {
+		return nil, fmt.Errorf("配置中不存在模型: %s", name)
+	}
+	return m.ToModel(), nil
+}
 
-		// 设置环境中的默认模型
-		if len(Models) > 0 {
-			global.SetCurrentModel(Models[0].Model, Models[0].ApiKey, Models[0].URL)
-		}
-	})
+// UseModel 按模型名称切换当前模型
+func UseModel(name string) error {
+	m, err := Current().Model(name)
+	if err != nil {
+		return err
+	}
+	global.SetCurrentModel(m)
+	return nil
 }
@@ -1,27 +1,93 @@
 package config
 
 import (
-	"os"
 	"sparrow-cli/env"
 	"sparrow-cli/global"
 	"testing"
 )
 
-func init() {
-	homePath := os.Getenv("SparrowCliHome")
-	if homePath == "" {
-		homePath = os.Getenv("HOME") + "/.sparrow-cli"
+// useTestHome 使用临时目录作为家目录并写入配置文件，测试结束后恢复原有的家目录与配置，避免依赖真实的家目录
+func useTestHome(t *testing.T, content string) string {
+	t.Helper()
+	home, previous, model := env.SparrowCliHome, current.Swap(nil), global.CurrentModel
+	t.Cleanup(func() {
+		env.SparrowCliHome = home
+		current.Store(previous)
+		global.CurrentModel = model
+	})
+
+	env.SparrowCliHome = t.TempDir()
+	if content != "" {
+		if err := WriteConfigFile(HomeConfigPath(), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
 	}
-	env.SparrowCliHome = homePath
+	return env.SparrowCliHome
 }
 
 func TestLoadConfig(t *testing.T) {
-	LoadConfig()
-	for _, model := range Models {
-		t.Logf("model: %s", model.Model)
+	useTestHome(t, `models:
+  - model: deepseek-chat
+    api_key: ${TEST_DEEPSEEK_KEY:-sk-test}
+    url: https://api.deepseek.com/chat/completions
+  - model: gpt-4o
+    api_key: sk-test
+    url: https://api.openai.com/v1/chat/completions
+logger:
+  level: debug
+`)
+
+	if err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	c := Current()
+	if len(c.Models) != 2 || c.Logger.Level != "debug" {
+		t.Errorf("Current() = %+v", c)
+	}
+	if m := c.Models[0]; m.ApiKey != "sk-test" || m.Provider != "deepseek" {
+		t.Errorf("models[0] = %+v, want resolved api_key and migrated provider", m)
+	}
+	if global.CurrentModel == nil || global.CurrentModel.Name != "deepseek-chat" {
+		t.Errorf("current model = %+v, want deepseek-chat", global.CurrentModel)
+	}
+	if version, err := FileVersion(HomeConfigPath()); err != nil || version != CurrentVersion {
+		t.Errorf("FileVersion() = %d, %v, want %d", version, err, CurrentVersion)
+	}
+}
+
+func TestReload(t *testing.T) {
+	useTestHome(t, "")
+
+	write := func(content string) {
+		t.Helper()
+		if err := WriteConfigFile(HomeConfigPath(), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("models:\n  - model: gpt-4o\n    api_key: sk-1\n    url: https://a.example.com/v1/chat/completions\n")
+	first, err := Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
 	}
 
-	t.Logf("logger: %+v", Logger)
+	write("models:\n  - model: gpt-4o\n    api_key: sk-1\n    url: https://b.example.com/v1/chat/completions\n")
+	second, err := Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if second == first || Current() != second {
+		t.Fatalf("Reload() did not replace the current config")
+	}
+	if m, ok := Current().FindModel("gpt-4o"); !ok || m.URL != "https://b.example.com/v1/chat/completions" {
+		t.Errorf("FindModel() = %+v, %v", m, ok)
+	}
 
-	t.Logf("current model: %+v", global.CurrentModel)
+	// 无效配置不会替换当前配置
+	write("models:\n  - model: gpt-4o\n    url: not-a-url\n")
+	if _, err := Reload(); err == nil {
+		t.Fatalf("Reload() should reject an invalid config")
+	}
+	if Current() != second {
+		t.Errorf("invalid config replaced the current config")
+	}
 }
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sparrow-cli/file"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document 可编辑的配置文件，基于 yaml.v3 节点修改，保留原有的注释与顺序
+type Document struct {
+	path string
+	doc  *yaml.Node
+}
+
+// Entry 配置文件中的一个叶子配置项
+type Entry struct {
+	Path  string // 点分路径，例如 models[0].url
+	Value string // 配置值
+}
+
+// pathElem 点分路径中的一段，键名或列表下标
+type pathElem struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// OpenDocument 打开配置文件，文件不存在时返回空文档，保存时才会创建文件。
+// param path 为配置文件路径。
+//
+// return 打开的文档和可能的错误。
+func OpenDocument(path string) (*Document, error) {
+	d := &Document{path: path}
+	if file.IsExist(path) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败 %s: %w", path, err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败 %s: %w", path, err)
+		}
+		if len(doc.Content) > 0 {
+			d.doc = &doc
+		}
+	}
+	if d.doc == nil {
+		root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setVersion(root, CurrentVersion)
+		d.doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	}
+	if d.doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("配置文件 %s 的顶层必须是映射", path)
+	}
+	return d, nil
+}
+
+// Path 返回配置文件路径
+func (d *Document) Path() string {
+	return d.path
+}
+
+// Get 按点分路径获取配置节点，例如 logger.level、models[1].url。
+//
+// return 配置节点和可能的错误。配置项不存在时返回错误。
+func (d *Document) Get(path string) (*yaml.Node, error) {
+	elems, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	n := d.doc.Content[0]
+	for i, e := range elems {
+		next := lookup(n, e)
+		if next == nil {
+			return nil, fmt.Errorf("配置项不存在: %s", formatPath(elems[:i+1]))
+		}
+		n = next
+	}
+	return n, nil
+}
+
+// Set 按点分路径设置配置值，不存在的中间映射会被创建，下标等于列表长度时追加新元素。
+// 值按 YAML 语法解析，例如 true、10、[a, b]，无法解析时按字符串处理。
+// param path 为点分路径。
+// param value 为配置值。
+//
+// return 可能的错误。
+func (d *Document) Set(path, value string) error {
+	elems, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	n := d.doc.Content[0]
+	for i, e := range elems {
+		last := i == len(elems)-1
+		var child *yaml.Node
+		if !last {
+			child = newContainer(elems[i+1])
+		} else {
+			child = parseValue(value)
+		}
+
+		switch {
+		case e.isIdx:
+			if n.Kind != yaml.SequenceNode {
+				return fmt.Errorf("%s 不是列表", formatPath(elems[:i]))
+			}
+			if e.index > len(n.Content) {
+				return fmt.Errorf("下标越界: %s，列表长度为 %d", formatPath(elems[:i+1]), len(n.Content))
+			}
+			if e.index == len(n.Content) {
+				n.Content = append(n.Content, child)
+			} else if last || isNull(n.Content[e.index]) {
+				replaceNode(n.Content[e.index], child)
+			}
+			n = n.Content[e.index]
+
+		default:
+			if n.Kind != yaml.MappingNode {
+				return fmt.Errorf("%s 不是映射", formatPath(elems[:i]))
+			}
+			idx := mappingIndex(n, e.key)
+			if idx < 0 {
+				n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.key}, child)
+				idx = len(n.Content) - 2
+			} else if last || isNull(n.Content[idx+1]) {
+				replaceNode(n.Content[idx+1], child)
+			}
+			n = n.Content[idx+1]
+		}
+	}
+	return nil
+}
+
+// Unset 按点分路径删除配置项。
+//
+// return 可能的错误。配置项不存在时返回错误。
+func (d *Document) Unset(path string) error {
+	elems, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	parent := d.doc.Content[0]
+	if len(elems) > 1 {
+		parentPath := formatPath(elems[:len(elems)-1])
+		if parent, err = d.Get(parentPath); err != nil {
+			return err
+		}
+	}
+
+	last := elems[len(elems)-1]
+	switch {
+	case last.isIdx && parent.Kind == yaml.SequenceNode && last.index < len(parent.Content):
+		parent.Content = append(parent.Content[:last.index], parent.Content[last.index+1:]...)
+	case !last.isIdx && parent.Kind == yaml.MappingNode && mappingIndex(parent, last.key) >= 0:
+		idx := mappingIndex(parent, last.key)
+		parent.Content = append(parent.Content[:idx], parent.Content[idx+2:]...)
+	default:
+		return fmt.Errorf("配置项不存在: %s", path)
+	}
+	return nil
+}
+
+// List 返回所有叶子配置项，按文件中的顺序排列
+func (d *Document) List() []Entry {
+	var entries []Entry
+	flatten(d.doc.Content[0], "", &entries)
+	return entries
+}
+
+// Bytes 返回文档的 YAML 内容
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(d.doc); err != nil {
+		return nil, fmt.Errorf("生成配置文件失败: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("生成配置文件失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Save 校验修改后的内容并写入文件，校验失败时不写入并返回 *ValidationError
+func (d *Document) Save() error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	if problems := ValidateData(d.path, data); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return WriteConfigFile(d.path, data)
+}
+
+// NodeString 将配置节点转换为文本，标量返回其值，映射与列表返回 YAML
+func NodeString(n *yaml.Node) string {
+	if n.Kind == yaml.ScalarNode {
+		return n.Value
+	}
+	data, err := yaml.Marshal(n)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(data), "\n")
+}
+
+// parsePath 解析点分路径，例如 models[1].url
+func parsePath(path string) ([]pathElem, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("配置路径不能为空")
+	}
+
+	var elems []pathElem
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		if i := strings.IndexByte(segment, '['); i >= 0 {
+			key = segment[:i]
+		}
+		if key != "" {
+			elems = append(elems, pathElem{key: key})
+		} else if len(elems) == 0 || !strings.HasPrefix(segment, "[") {
+			return nil, fmt.Errorf("配置路径无效: %s", path)
+		}
+
+		rest := segment[len(key):]
+		for rest != "" {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("配置路径无效: %s", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("配置路径中的下标无效: %s", path)
+			}
+			elems = append(elems, pathElem{index: index, isIdx: true})
+			rest = rest[end+1:]
+		}
+	}
+	return elems, nil
+}
+
+// formatPath 将路径片段还原为点分路径
+func formatPath(elems []pathElem) string {
+	var sb strings.Builder
+	for _, e := range elems {
+		if e.isIdx {
+			fmt.Fprintf(&sb, "[%d]", e.index)
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(e.key)
+	}
+	return sb.String()
+}
+
+// lookup 返回节点中路径片段对应的子节点，不存在时返回 nil
+func lookup(n *yaml.Node, e pathElem) *yaml.Node {
+	if e.isIdx {
+		if n.Kind == yaml.SequenceNode && e.index < len(n.Content) {
+			return n.Content[e.index]
+		}
+		return nil
+	}
+	return child(n, e.key)
+}
+
+// newContainer 根据下一段路径创建空的映射或列表
+func newContainer(next pathElem) *yaml.Node {
+	if next.isIdx {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// parseValue 按 YAML 语法解析配置值，无法解析时作为字符串
+func parseValue(value string) *yaml.Node {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err == nil && len(doc.Content) == 1 {
+		n := doc.Content[0]
+		n.Style &^= yaml.FlowStyle
+		return n
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// isNull 判断节点是否为空值，例如只写了键名的 logger:
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// replaceNode 用新节点替换旧节点的内容，保留旧节点上的注释
+func replaceNode(old, n *yaml.Node) {
+	head, line, foot := old.HeadComment, old.LineComment, old.FootComment
+	*old = *n
+	old.HeadComment, old.LineComment, old.FootComment = head, line, foot
+}
+
+// flatten 收集节点下的所有叶子配置项
+func flatten(n *yaml.Node, prefix string, entries *[]Entry) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		if len(n.Content) == 0 && prefix != "" {
+			*entries = append(*entries, Entry{Path: prefix, Value: "{}"})
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			flatten(n.Content[i+1], joinPath(prefix, n.Content[i].Value), entries)
+		}
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			*entries = append(*entries, Entry{Path: prefix, Value: "[]"})
+		}
+		for i, item := range n.Content {
+			flatten(item, fmt.Sprintf("%s[%d]", prefix, i), entries)
+		}
+	default:
+		*entries = append(*entries, Entry{Path: prefix, Value: n.Value})
+	}
+}
+
+// AddModel 以已配置的模型为模板添加新模型，新模型与模板使用相同的地址、密钥、服务商与传输设置，
+// 写入定义模板模型的配置文件。模板的价格与 Azure 部署名称只适用于模板模型，不会被复制。
+// param source 为模板模型名称。
+// param name 为新模型名称。
+// param deployment 为新模型的 Azure 部署名称，为空时不设置。
+//
+// return 写入的配置文件路径和可能的错误。
+func AddModel(source, name, deployment string) (string, error) {
+	if _, ok := Current().FindModel(name); ok {
+		return "", fmt.Errorf("模型 %s 已在配置中", name)
+	}
+	for _, path := range Paths() {
+		if !file.IsExist(path) {
+			continue
+		}
+		d, err := OpenDocument(path)
+		if err != nil {
+			return "", err
+		}
+		models, err := d.Get("models")
+		if err != nil || models.Kind != yaml.SequenceNode {
+			continue
+		}
+		template := findModel(models, source)
+		if template == nil {
+			continue
+		}
+
+		item := cloneNode(template)
+		item.Content[mappingIndex(item, "model")+1].Value = name
+		for _, key := range []string{"price", "deployment"} {
+			if idx := mappingIndex(item, key); idx >= 0 {
+				item.Content = append(item.Content[:idx], item.Content[idx+2:]...)
+			}
+		}
+		if deployment != "" {
+			item.Content = append(item.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "deployment"},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: deployment})
+		}
+		models.Content = append(models.Content, item)
+		return path, d.Save()
+	}
+	return "", fmt.Errorf("配置文件中不存在模型: %s", source)
+}
+
+// cloneNode 深拷贝节点，去掉注释以免重复
+func cloneNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.HeadComment, c.LineComment, c.FootComment = "", "", ""
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const editSample = `# 模型列表
+models:
+  - model: gpt-4o # 默认模型
+    api_key: sk-1234567890
+    url: https://api.openai.com/v1/chat/completions
+  - model: deepseek-chat
+    api_key: ${DEEPSEEK_API_KEY:-sk-x}
+    url: https://api.deepseek.com/chat/completions
+logger:
+  level: info # 日志级别
+attach:
+`
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "logger.level", want: "logger.level"},
+		{path: "models[1].url", want: "models[1].url"},
+		{path: "attach.ignore[0]", want: "attach.ignore[0]"},
+		{path: "", wantErr: true},
+		{path: "models[x]", wantErr: true},
+		{path: "models[1", wantErr: true},
+		{path: "logger..level", wantErr: true},
+	}
+	for _, tt := range tests {
+		elems, err := parsePath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if err == nil && formatPath(elems) != tt.want {
+			t.Errorf("parsePath(%q) = %q, want %q", tt.path, formatPath(elems), tt.want)
+		}
+	}
+}
+
+func TestDocumentEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(editSample), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d, err := OpenDocument(path)
+	if err != nil {
+		t.Fatalf("OpenDocument() error = %v", err)
+	}
+
+	if n, err := d.Get("models[1].url"); err != nil || NodeString(n) != "https://api.deepseek.com/chat/completions" {
+		t.Errorf("Get(models[1].url) = %v, %v", n, err)
+	}
+	if _, err := d.Get("models[5].url"); err == nil {
+		t.Errorf("Get(models[5].url) should fail")
+	}
+
+	steps := []struct{ path, value string }{
+		{"logger.level", "debug"},
+		{"logger.max_age", "7"},
+		{"attach.ignore[0]", "*.log"},
+		{"models[2].model", "llama3"},
+		{"models[2].provider", "ollama"},
+		{"models[2].url", "http://localhost:11434/v1/chat/completions"},
+	}
+	for _, s := range steps {
+		if err := d.Set(s.path, s.value); err != nil {
+			t.Fatalf("Set(%s) error = %v", s.path, err)
+		}
+	}
+	if err := d.Set("models[9].url", "x"); err == nil {
+		t.Errorf("Set(models[9].url) should fail")
+	}
+	if err := d.Unset("models[0].api_key"); err != nil {
+		t.Fatalf("Unset() error = %v", err)
+	}
+	if err := d.Set("models[0].credential", "openai"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	for _, want := range []string{"# 模型列表", "# 默认模型", "level: debug # 日志级别", "max_age: 7", "- '*.log'", "credential: openai"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("saved config missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "sk-1234567890") {
+		t.Errorf("unset api_key still present:\n%s", content)
+	}
+
+	entries := d.List()
+	if len(entries) == 0 || entries[0].Path != "models[0].model" || entries[0].Value != "gpt-4o" {
+		t.Errorf("List()[0] = %+v", entries)
+	}
+}
+
+func TestDocumentSaveRejectsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(editSample), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d, err := OpenDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("logger.level", "loud"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Save(); err == nil {
+		t.Fatalf("Save() should reject invalid logger.level")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != editSample {
+		t.Errorf("config file was modified after a rejected save")
+	}
+}
+
+func TestAddModel(t *testing.T) {
+	useTestHome(t, `version: 2
+models:
+  - model: gpt-4o # 默认模型
+    api_key: ${TEST_OPENAI_KEY:-sk-test}
+    url: https://api.openai.com/v1/chat/completions
+    provider: openai
+    price:
+      input: 2.5
+      output: 10
+`)
+	if err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := AddModel("gpt-4o", "gpt-4o-mini", "")
+	if err != nil {
+		t.Fatalf("AddModel() error = %v", err)
+	}
+	if path != HomeConfigPath() {
+		t.Errorf("AddModel() path = %s, want %s", path, HomeConfigPath())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), "${TEST_OPENAI_KEY:-sk-test}") != 2 || strings.Count(string(data), "默认模型") != 1 {
+		t.Errorf("added model should reuse the unresolved api_key without comments:\n%s", data)
+	}
+
+	c, err := Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := c.FindModel("gpt-4o-mini")
+	if !ok || m.URL != "https://api.openai.com/v1/chat/completions" || m.ApiKey != "sk-test" || m.Price != nil {
+		t.Errorf("FindModel(gpt-4o-mini) = %+v, %v", m, ok)
+	}
+
+	if _, err := AddModel("gpt-4o", "gpt-4o-mini", ""); err == nil {
+		t.Errorf("AddModel() should reject an existing model")
+	}
+	if _, err := AddModel("missing", "other", ""); err == nil {
+		t.Errorf("AddModel() should reject an unknown template")
+	}
+}
+
+func TestAddModelDeploymentAndProject(t *testing.T) {
+	useTestHome(t, `version: 2
+models:
+  - model: gpt-4o
+    api_key: sk-home-1234567890
+    url: https://demo.openai.azure.com
+    provider: azure
+    deployment: gpt4o-prod
+`)
+	project := t.TempDir()
+	t.Chdir(project)
+	projectPath := filepath.Join(project, ProjectConfigFileName)
+	if err := os.WriteFile(projectPath, []byte(`version: 2
+models:
+  - model: llama3
+    url: http://localhost:11434/v1/chat/completions
+    provider: ollama
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Azure 模型使用新的部署名称，不沿用模板的部署
+	if _, err := AddModel("gpt-4o", "gpt-4o-mini", "mini-prod"); err != nil {
+		t.Fatalf("AddModel() error = %v", err)
+	}
+	// 模板只在项目配置中定义时写入项目配置，与其他配置合并后校验
+	path, err := AddModel("llama3", "qwen2.5", "")
+	if err != nil {
+		t.Fatalf("AddModel() with a project template error = %v", err)
+	}
+	if path != projectPath {
+		t.Errorf("AddModel() path = %s, want %s", path, projectPath)
+	}
+
+	c, err := Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := c.FindModel("gpt-4o-mini"); !ok || m.Deployment != "mini-prod" {
+		t.Errorf("FindModel(gpt-4o-mini) = %+v, %v, want deployment mini-prod", m, ok)
+	}
+	if m, ok := c.FindModel("qwen2.5"); !ok || m.URL != "http://localhost:11434/v1/chat/completions" {
+		t.Errorf("FindModel(qwen2.5) = %+v, %v", m, ok)
+	}
+}
+
+func TestDocumentSaveProjectOverride(t *testing.T) {
+	useTestHome(t, `version: 2
+models:
+  - model: gpt-4o
+    api_key: sk-home-1234567890
+    url: https://api.openai.com/v1/chat/completions
+`)
+	project := t.TempDir()
+	t.Chdir(project)
+	path := filepath.Join(project, ProjectConfigFileName)
+
+	// 项目配置只覆盖模型的价格，单独看缺少 url 与 api_key，与家目录配置合并后有效
+	d, err := OpenDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []struct{ path, value string }{
+		{"models[0].model", "gpt-4o"},
+		{"models[0].price.input", "3"},
+		{"logger.level", "debug"},
+	} {
+		if err := d.Set(step.path, step.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Save(); err != nil {
+		t.Fatalf("Save() of a partial project override error = %v", err)
+	}
+
+	// 再次打开已存在的项目配置修改，与 config set --project 相同
+	d, err = OpenDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("logger.level", "info"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// 合并后的校验同样拒绝把家目录的密钥发往项目指定的地址
+	if err := d.Set("models[0].url", "https://attacker.example.com/v1/chat/completions"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Save(); err == nil || !strings.Contains(err.Error(), "models[0].url 由项目配置设置") {
+		t.Errorf("Save() error = %v, want credential redirect problem", err)
+	}
+}
@@ -1,16 +1,87 @@
 package config
 
+import (
+	"sparrow-cli/global"
+	"time"
+)
+
 // ProjectConfig 项目配置
 type ProjectConfig struct {
-	Models []ModelConfig    `yaml:"models"`
-	Logger LoggerConfigData `yaml:"logger"`
+	Version       int                      `yaml:"version"` // 配置文件版本，旧版本的配置文件会被自动迁移
+	Models        []ModelConfig            `yaml:"models"`
+	DefaultPrompt string                   `yaml:"default_prompt"` // 默认系统提示词名称，对应提示词库中的文件
+	Logger        LoggerConfigData         `yaml:"logger"`
+	History       HistoryConfigData        `yaml:"history"`
+	Attach        AttachConfigData         `yaml:"attach"`
+	Tools         ToolsConfigData          `yaml:"tools"`
+	Profiles      map[string]ProfileConfig `yaml:"profiles"` // 配置档案，按名称通过 --profile 或 /profile 选择
 }
 
 // ModelConfig 模型配置
 type ModelConfig struct {
-	Model  string `yaml:"model"`
-	ApiKey string `yaml:"api_key"`
-	URL    string `yaml:"url"`
+	Model      string            `yaml:"model"`
+	ApiKey     string            `yaml:"api_key"`               // API密钥，支持 ${VAR}、file:<路径>、cmd:<命令> 引用
+	URL        string            `yaml:"url"`                   // API地址，支持 ${VAR} 引用
+	Provider   string            `yaml:"provider,omitempty"`    // 模型服务商，为空时按 openai 处理
+	Credential string            `yaml:"credential,omitempty"`  // 加密凭据存储中的凭据名称，设置后代替 api_key
+	Deployment string            `yaml:"deployment,omitempty"`  // Azure 部署名称，为空时与 model 相同
+	APIVersion string            `yaml:"api_version,omitempty"` // Azure 接口版本，例如 2024-10-21，为空时使用默认版本
+	Price      *PriceConfig      `yaml:"price,omitempty"`       // 模型价格，用于估算费用
+	Headers    map[string]string `yaml:"headers,omitempty"`     // 额外的请求头，值支持与 api_key 相同的引用
+	Transport  TransportConfig   `yaml:"transport,omitempty"`   // 网络传输设置：超时、代理与 TLS
+
+	sources map[string]string // 会解析引用的字段的来源文件，键为字段路径，例如 headers.X-Token，加载时填写
+}
+
+// TransportConfig 定义了模型请求的网络传输设置，未设置的超时使用默认值
+type TransportConfig struct {
+	ConnectTimeout     time.Duration `yaml:"connect_timeout"`      // 建立连接（含 TLS 握手）的超时时间，例如 10s
+	FirstByteTimeout   time.Duration `yaml:"first_byte_timeout"`   // 发出请求后等待响应头的超时时间
+	IdleTimeout        time.Duration `yaml:"idle_timeout"`         // 流式输出中两次收到数据的最长间隔
+	Proxy              string        `yaml:"proxy"`                // 代理地址，例如 http://127.0.0.1:7890、socks5://127.0.0.1:1080，为空时使用环境变量
+	CAFile             string        `yaml:"ca_file"`              // 额外信任的 CA 证书文件（PEM）
+	CertFile           string        `yaml:"cert_file"`            // 客户端证书文件（PEM），需同时设置 key_file
+	KeyFile            string        `yaml:"key_file"`             // 客户端证书私钥文件（PEM）
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify"` // 跳过服务端证书校验，仅用于使用自签名证书的内部网关
+}
+
+// PriceConfig 模型价格，按每百万 token 计
+type PriceConfig struct {
+	Input    float64 `yaml:"input"`    // 每百万输入 token 的价格
+	Output   float64 `yaml:"output"`   // 每百万输出 token 的价格
+	Currency string  `yaml:"currency"` // 货币符号，默认 $
+}
+
+// ToModel 转换为发送请求使用的模型
+func (m ModelConfig) ToModel() *global.Model {
+	model := &global.Model{
+		Name:       m.Model,
+		ApiKey:     m.ApiKey,
+		URL:        m.URL,
+		Provider:   global.ParseProvider(m.Provider),
+		Deployment: m.Deployment,
+		APIVersion: m.APIVersion,
+	}
+	if m.Price != nil {
+		model.InputPrice = m.Price.Input
+		model.OutputPrice = m.Price.Output
+		model.Currency = m.Price.Currency
+	}
+	if model.Currency == "" {
+		model.Currency = "$"
+	}
+	model.Headers = m.Headers
+	model.Transport = global.Transport{
+		ConnectTimeout:     m.Transport.ConnectTimeout,
+		FirstByteTimeout:   m.Transport.FirstByteTimeout,
+		IdleTimeout:        m.Transport.IdleTimeout,
+		Proxy:              m.Transport.Proxy,
+		CAFile:             m.Transport.CAFile,
+		CertFile:           m.Transport.CertFile,
+		KeyFile:            m.Transport.KeyFile,
+		InsecureSkipVerify: m.Transport.InsecureSkipVerify,
+	}
+	return model
 }
 
 // LoggerConfigData 定义了日志配置
@@ -21,3 +92,97 @@ type LoggerConfigData struct {
 	MaxBackups uint16 `yaml:"max_backups"` // 日志备份文件最大数量
 	Compress   bool   `yaml:"compress"`    // 是否压缩日志文件
 }
+
+// HistoryConfigData 定义了输入历史配置
+type HistoryConfigData struct {
+	MaxEntries    int  `yaml:"max_entries"`     // 最多保留的历史条数，0 表示使用默认值
+	MaxEntryBytes int  `yaml:"max_entry_bytes"` // 单条历史的最大字节数，0 表示使用默认值
+	IgnoreSpace   bool `yaml:"ignore_space"`    // 是否不记录以空格开头的输入
+}
+
+// AttachConfigData 定义了 @ 文件引用的配置
+type AttachConfigData struct {
+	MaxFileBytes  int      `yaml:"max_file_bytes"`  // 单个文件的最大字节数，0 表示使用默认值
+	MaxTotalBytes int      `yaml:"max_total_bytes"` // 一次提问附加内容的最大总字节数，0 表示使用默认值
+	MaxFiles      int      `yaml:"max_files"`       // 一次提问最多附加的文件数，0 表示使用默认值
+	MaxImageBytes int      `yaml:"max_image_bytes"` // 单张图片的最大字节数，0 表示使用默认值
+	Ignore        []string `yaml:"ignore"`          // 额外的忽略规则，语法与 .gitignore 相同
+}
+
+// Permission 工具权限
+type Permission string
+
+const (
+	PermissionAsk   Permission = "ask"   // 每次使用前询问
+	PermissionAllow Permission = "allow" // 直接允许
+	PermissionDeny  Permission = "deny"  // 禁止使用
+)
+
+// Or 权限未配置时返回默认权限
+func (p Permission) Or(def Permission) Permission {
+	if p == "" {
+		return def
+	}
+	return p
+}
+
+// ToolsConfigData 定义了对话中各工具的权限
+type ToolsConfigData struct {
+	RunCode   Permission `yaml:"run_code"`   // 运行代码块，默认 ask
+	WriteFile Permission `yaml:"write_file"` // 将代码块写入文件，默认 allow
+	Clipboard Permission `yaml:"clipboard"`  // 复制到剪贴板，默认 allow
+}
+
+// DefaultTools 未配置时的工具权限
+var DefaultTools = ToolsConfigData{RunCode: PermissionAsk, WriteFile: PermissionAllow, Clipboard: PermissionAllow}
+
+// Get 按配置项名称返回权限，例如 run_code，未知的名称返回空权限
+func (t ToolsConfigData) Get(key string) Permission {
+	switch key {
+	case "run_code":
+		return t.RunCode
+	case "write_file":
+		return t.WriteFile
+	case "clipboard":
+		return t.Clipboard
+	default:
+		return ""
+	}
+}
+
+// Merge 用 override 中已配置的权限覆盖当前权限
+func (t ToolsConfigData) Merge(override ToolsConfigData) ToolsConfigData {
+	if override.RunCode != "" {
+		t.RunCode = override.RunCode
+	}
+	if override.WriteFile != "" {
+		t.WriteFile = override.WriteFile
+	}
+	if override.Clipboard != "" {
+		t.Clipboard = override.Clipboard
+	}
+	return t
+}
+
+// ProfileConfig 配置档案，组合模型、系统提示词、温度与工具权限，未设置的项继承自 extends 指定的档案
+type ProfileConfig struct {
+	Name        string          `yaml:"-"`           // 档案名称，即 profiles 中的键
+	Extends     string          `yaml:"extends"`     // 继承的基础档案名称
+	Description string          `yaml:"description"` // 档案描述
+	Model       string          `yaml:"model"`       // 使用的模型，对应 models 中的 model
+	Prompt      string          `yaml:"prompt"`      // 系统提示词名称，对应提示词库中的提示词
+	Temperature *float64        `yaml:"temperature"` // 温度，为空时使用提示词的温度
+	Tools       ToolsConfigData `yaml:"tools"`       // 工具权限，覆盖全局的 tools 配置
+	Fallback    []string        `yaml:"fallback"`    // 模型不可用或被限流时依次尝试的备用模型
+	Routes      []RouteConfig   `yaml:"routes"`      // 按提问内容选择模型的路由规则，使用第一条命中的规则
+}
+
+// RouteConfig 路由规则，所有已设置的条件都满足时由该规则的模型回答
+type RouteConfig struct {
+	Model       string   `yaml:"model"`       // 命中时使用的模型，对应 models 中的 model
+	MinLength   int      `yaml:"min_length"`  // 提问（含附件内容）的字符数不少于该值
+	MaxLength   int      `yaml:"max_length"`  // 提问的字符数不超过该值，0 表示不限制
+	Attachments bool     `yaml:"attachments"` // 提问附加了文件或图片
+	Images      bool     `yaml:"images"`      // 提问附加了图片
+	Keywords    []string `yaml:"keywords"`    // 提问包含任一关键词，不区分大小写
+}
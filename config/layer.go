@@ -0,0 +1,268 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sparrow-cli/file"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFileName 项目级配置文件名
+const ProjectConfigFileName = ".sparrow-cli.yaml"
+
+// FindProjectConfigs 从目录 dir 向上查找所有项目级配置文件。
+// param dir 为开始查找的目录，通常为当前工作目录。
+//
+// return 找到的配置文件，越靠近 dir 的文件越靠后（优先级越高）。
+func FindProjectConfigs(dir string) []string {
+	var found []string
+	for current := dir; ; {
+		path := filepath.Join(current, ProjectConfigFileName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			found = append([]string{path}, found...)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return found
+		}
+		current = parent
+	}
+}
+
+// mergeLayers 依次读取配置文件并按优先级合并。
+// param paths 为配置文件路径，后面的文件覆盖前面的文件。
+//
+// return 合并后的文档节点和可能的错误。
+func mergeLayers(paths []string) (*yaml.Node, error) {
+	return mergeEditedLayers(paths, nil)
+}
+
+// mergeEditedLayers 与 mergeLayers 相同，但 edits 中的文件使用修改后的内容代替磁盘上的内容
+func mergeEditedLayers(paths []string, edits map[string][]byte) (*yaml.Node, error) {
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, path := range paths {
+		data, ok, err := readLayer(path, edits)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败 %s: %w", path, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("配置文件 %s 的顶层必须是映射", path)
+		}
+
+		// 旧版本的配置在内存中迁移后再合并，文件本身由 Migrate 或 config migrate 升级
+		if _, err := migrateNode(root); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		annotate(root, path)
+		mergeMapping(merged, root)
+	}
+	return merged, nil
+}
+
+// readLayer 读取配置文件内容，edits 中的文件返回修改后的内容。
+//
+// return 文件内容、文件是否存在和可能的错误。
+func readLayer(path string, edits map[string][]byte) ([]byte, bool, error) {
+	if data, ok := edits[path]; ok {
+		return data, true, nil
+	}
+	if !file.IsExist(path) {
+		return nil, false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("读取配置文件失败 %s: %w", path, err)
+	}
+	return data, true, nil
+}
+
+// layerPaths 返回 path 参与合并的配置文件列表：path 是家目录配置或当前目录向上找到的项目配置时返回 Paths()，
+// 是当前目录中尚未创建的项目配置时追加在最后，其他文件返回 nil
+func layerPaths(path string) []string {
+	path = filepath.Clean(path)
+	paths := Paths()
+	for _, p := range paths {
+		if filepath.Clean(p) == path {
+			return paths
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil && path == filepath.Join(cwd, ProjectConfigFileName) {
+		return append(paths, path)
+	}
+	return nil
+}
+
+// annotate 在所有标量叶子节点的行尾注释中记录来源文件
+func annotate(n *yaml.Node, source string) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		n.HeadComment, n.FootComment = "", ""
+		n.LineComment = source
+	case yaml.MappingNode:
+		n.HeadComment, n.FootComment = "", ""
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			n.Content[i].HeadComment, n.Content[i].LineComment, n.Content[i].FootComment = "", "", ""
+			annotate(n.Content[i+1], source)
+		}
+	case yaml.SequenceNode:
+		n.HeadComment, n.FootComment = "", ""
+		for _, item := range n.Content {
+			annotate(item, source)
+		}
+	}
+}
+
+// mergeMapping 将 src 映射合并到 dst 映射中：
+//   - models 按 model 名称合并，src 中的模型排在前面，未被覆盖的 dst 模型保留在后面
+//   - ignore 等字符串列表追加合并
+//   - 嵌套映射递归合并，其余值直接覆盖
+func mergeMapping(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		idx := mappingIndex(dst, key.Value)
+		if idx < 0 {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+		current := dst.Content[idx+1]
+
+		switch {
+		case key.Value == "models" && current.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			dst.Content[idx+1] = mergeModels(current, value)
+		case key.Value == "ignore" && current.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			current.Content = append(current.Content, value.Content...)
+		case current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeMapping(current, value)
+		default:
+			dst.Content[idx+1] = value
+		}
+	}
+}
+
+// mergeModels 按 model 名称合并模型列表
+func mergeModels(dst, src *yaml.Node) *yaml.Node {
+	merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	used := make(map[*yaml.Node]bool)
+
+	for _, item := range src.Content {
+		if base := findModel(dst, modelName(item)); base != nil && item.Kind == yaml.MappingNode {
+			mergeMapping(base, item)
+			used[base] = true
+			merged.Content = append(merged.Content, base)
+			continue
+		}
+		merged.Content = append(merged.Content, item)
+	}
+	for _, item := range dst.Content {
+		if !used[item] {
+			merged.Content = append(merged.Content, item)
+		}
+	}
+	return merged
+}
+
+// findModel 在模型列表中查找指定名称的模型节点
+func findModel(models *yaml.Node, name string) *yaml.Node {
+	if name == "" {
+		return nil
+	}
+	for _, item := range models.Content {
+		if modelName(item) == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// modelName 返回模型节点的 model 字段
+func modelName(n *yaml.Node) string {
+	if n.Kind != yaml.MappingNode {
+		return ""
+	}
+	if idx := mappingIndex(n, "model"); idx >= 0 {
+		return n.Content[idx+1].Value
+	}
+	return ""
+}
+
+// mappingIndex 返回映射中指定键所在的下标，不存在时返回 -1
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Effective 返回合并后的有效配置，每个值的行尾注释标明其来源文件，API 密钥会被遮盖。
+//
+// return YAML 文本和可能的错误。
+func Effective() ([]byte, error) {
+	effective := Current().effective
+	if effective == nil {
+		return nil, fmt.Errorf("配置尚未加载")
+	}
+
+	masked := maskSecrets(effective)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(masked); err != nil {
+		return nil, fmt.Errorf("输出有效配置失败: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("输出有效配置失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// maskSecrets 复制节点树并遮盖 api_key 与请求头的值
+func maskSecrets(n *yaml.Node) *yaml.Node {
+	cp := *n
+	cp.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		cp.Content[i] = maskSecrets(child)
+	}
+	if cp.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(cp.Content); i += 2 {
+			if cp.Content[i].Value == "api_key" && cp.Content[i+1].Kind == yaml.ScalarNode {
+				cp.Content[i+1].Value = MaskSecret(cp.Content[i+1].Value)
+			}
+			if cp.Content[i].Value == "headers" && cp.Content[i+1].Kind == yaml.MappingNode {
+				headers := cp.Content[i+1]
+				for j := 1; j < len(headers.Content); j += 2 {
+					headers.Content[j].Value = MaskSecret(headers.Content[j].Value)
+				}
+			}
+		}
+	}
+	return &cp
+}
+
+// MaskSecret 遮盖敏感信息，仅保留末尾 4 个字符
+func MaskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sparrow-cli/file"
+	"sparrow-cli/global"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion 当前程序使用的配置文件版本，没有 version 字段的配置文件视为版本 1
+const CurrentVersion = 2
+
+// Migration 将配置从一个版本升级到下一个版本
+type Migration struct {
+	From        int                                     // 迁移前的版本，迁移后为 From+1
+	Description string                                  // 迁移说明
+	Apply       func(root *yaml.Node) ([]string, error) // 修改配置根节点，返回具体的修改内容
+}
+
+// migrations 按版本顺序注册的迁移，新增版本时在末尾追加并增加 CurrentVersion
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "为未设置 provider 的模型按接口地址推断服务商",
+		Apply:       inferProviders,
+	},
+}
+
+// MigrationResult 一次配置文件迁移的结果
+type MigrationResult struct {
+	Path    string   // 配置文件路径
+	From    int      // 迁移前的版本
+	To      int      // 迁移后的版本
+	Backup  string   // 原文件的备份路径，仅在写回文件时设置
+	Changes []string // 修改内容
+}
+
+// String 输出迁移报告
+func (r *MigrationResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "配置文件 %s 已从版本 %d 升级到版本 %d", r.Path, r.From, r.To)
+	if r.Backup != "" {
+		fmt.Fprintf(&sb, "，原文件备份于 %s", r.Backup)
+	}
+	for _, c := range r.Changes {
+		sb.WriteString("\n  - " + c)
+	}
+	return sb.String()
+}
+
+// Migrate 将配置文件升级到当前版本，升级前将原文件备份为同目录下的 tar.gz 文件。
+// param path 为配置文件路径。
+//
+// return 迁移结果和可能的错误。文件不存在或已是当前版本时结果为 nil。
+func Migrate(path string) (*MigrationResult, error) {
+	if !file.IsExist(path) {
+		return nil, nil
+	}
+	d, err := OpenDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := migrateNode(d.doc.Content[0])
+	if err != nil || result == nil {
+		return nil, err
+	}
+	result.Path = path
+
+	data, err := d.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if problems := ValidateData(path, data); len(problems) > 0 {
+		return nil, fmt.Errorf("迁移后的配置无效，未修改原文件: %w", &ValidationError{Problems: problems})
+	}
+
+	// 备份中含有密钥，权限与配置文件一致
+	backup := fmt.Sprintf("%s.v%d.%s.tar.gz", path, result.From, time.Now().Format("20060102150405"))
+	if err := file.CompressFileToTarGz(path, backup); err != nil {
+		return nil, fmt.Errorf("备份配置文件失败: %w", err)
+	}
+	if err := os.Chmod(backup, 0600); err != nil {
+		return nil, fmt.Errorf("设置备份文件权限失败: %w", err)
+	}
+	result.Backup = backup
+
+	if err := WriteConfigFile(path, data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FileVersion 返回配置文件的版本，没有 version 字段时为 1
+func FileVersion(path string) (int, error) {
+	d, err := OpenDocument(path)
+	if err != nil {
+		return 0, err
+	}
+	return configVersion(d.doc.Content[0])
+}
+
+// migrateNode 在配置根节点上依次执行迁移并更新 version 字段。
+//
+// return 迁移结果和可能的错误。已是当前版本时结果为 nil，版本高于当前程序支持的版本时返回错误。
+func migrateNode(root *yaml.Node) (*MigrationResult, error) {
+	version, err := configVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("配置文件版本 %d 高于当前程序支持的版本 %d，请升级 sparrow-cli", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return nil, nil
+	}
+
+	result := &MigrationResult{From: version, To: CurrentVersion}
+	for _, m := range migrations {
+		if m.From < version {
+			continue
+		}
+		changes, err := m.Apply(root)
+		if err != nil {
+			return nil, fmt.Errorf("从版本 %d 迁移失败（%s）: %w", m.From, m.Description, err)
+		}
+		result.Changes = append(result.Changes, changes...)
+	}
+	setVersion(root, CurrentVersion)
+	result.Changes = append(result.Changes, fmt.Sprintf("设置 version: %d", CurrentVersion))
+	return result, nil
+}
+
+// configVersion 读取配置根节点的 version 字段，未设置时为 1
+func configVersion(root *yaml.Node) (int, error) {
+	n := child(root, "version")
+	if n == nil || isNull(n) {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(n.Value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("第 %d 行的 version 无效: %q", n.Line, n.Value)
+	}
+	return version, nil
+}
+
+// setVersion 设置 version 字段，字段不存在时插入到配置最前面
+func setVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if n := child(root, "version"); n != nil {
+		replaceNode(n, value)
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version", HeadComment: "配置文件版本，由 sparrow-cli 自动维护"}
+	// 原本位于第一个键上的文件头注释保留在最前面
+	if len(root.Content) > 0 {
+		key.HeadComment = root.Content[0].HeadComment + "\n\n" + key.HeadComment
+		key.HeadComment = strings.TrimPrefix(key.HeadComment, "\n\n")
+		root.Content[0].HeadComment = ""
+	}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// providerHosts 接口地址与服务商的对应关系
+var providerHosts = []struct {
+	host     string
+	provider global.Provider
+}{
+	{"deepseek.com", global.ProviderDeepSeek},
+	{"dashscope.aliyuncs.com", global.ProviderQwen},
+	{"bigmodel.cn", global.ProviderZhipu},
+}
+
+// inferProviders 版本 1 → 2：为未设置 provider 的模型按接口地址推断服务商，
+// 版本 1 的配置中没有 provider 字段，所有模型都按 openai 格式请求。
+func inferProviders(root *yaml.Node) ([]string, error) {
+	models := child(root, "models")
+	if models == nil || models.Kind != yaml.SequenceNode {
+		return nil, nil
+	}
+
+	var changes []string
+	for i, item := range models.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if p := child(item, "provider"); p != nil && p.Value != "" {
+			continue
+		}
+		provider := global.ProviderOpenAI
+		if u := child(item, "url"); u != nil {
+			provider = providerForURL(u.Value)
+		}
+		item.Content = append(item.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "provider"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(provider)})
+		changes = append(changes, fmt.Sprintf("models[%d].provider 设置为 %s", i, provider))
+	}
+	return changes, nil
+}
+
+// providerForURL 按接口地址推断服务商，无法识别时视为兼容 OpenAI 接口
+func providerForURL(raw string) global.Provider {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return global.ProviderOpenAI
+	}
+	if u.Port() == "11434" {
+		return global.ProviderOllama
+	}
+	host := u.Hostname()
+	for _, ph := range providerHosts {
+		if host == ph.host || strings.HasSuffix(host, "."+ph.host) {
+			return ph.provider
+		}
+	}
+	return global.ProviderOpenAI
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	v1 := `# 团队配置
+models:
+  - model: deepseek-chat
+    api_key: sk-1
+    url: https://api.deepseek.com/chat/completions
+  - model: qwen-plus
+    api_key: sk-2
+    url: https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions
+  - model: llama3
+    api_key: none
+    url: http://localhost:11434/v1/chat/completions
+  - model: gpt-4o
+    api_key: sk-3
+    url: https://api.openai.com/v1/chat/completions
+    provider: openai
+`
+	if err := os.WriteFile(path, []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Migrate(path)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if result == nil || result.From != 1 || result.To != CurrentVersion {
+		t.Fatalf("Migrate() = %+v", result)
+	}
+	if len(result.Changes) != 4 {
+		t.Errorf("Changes = %q, want 3 provider changes and the version bump", result.Changes)
+	}
+
+	info, err := os.Stat(result.Backup)
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("backup mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	d, err := OpenDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"version":            "2",
+		"models[0].provider": "deepseek",
+		"models[1].provider": "qwen",
+		"models[2].provider": "ollama",
+		"models[3].provider": "openai",
+	} {
+		n, err := d.Get(path)
+		if err != nil || n.Value != want {
+			t.Errorf("%s = %v, %v, want %s", path, n, err, want)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# 团队配置") {
+		t.Errorf("header comment not preserved:\n%s", data)
+	}
+
+	// 已是当前版本时不再迁移
+	if result, err := Migrate(path); err != nil || result != nil {
+		t.Errorf("second Migrate() = %+v, %v, want nil", result, err)
+	}
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("version: 99\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Migrate(path); err == nil {
+		t.Errorf("Migrate() should reject a newer config version")
+	}
+	if problems := Validate([]string{path}); len(problems) == 0 {
+		t.Errorf("Validate() should report a newer config version")
+	}
+}
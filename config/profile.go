@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileNames 返回按名称排序的所有配置档案名称
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile 按名称获取配置档案并解析继承关系，子档案中已设置的项覆盖基础档案。
+// param name 为档案名称。
+//
+// return 解析后的档案和可能的错误。档案不存在或继承关系存在循环时返回错误。
+func (c *Config) Profile(name string) (ProfileConfig, error) {
+	chain, err := profileChain(c.Profiles, name)
+	if err != nil {
+		return ProfileConfig{}, err
+	}
+
+	// 从最基础的档案开始依次覆盖
+	var resolved ProfileConfig
+	for i := len(chain) - 1; i >= 0; i-- {
+		p := c.Profiles[chain[i]]
+		if p.Description != "" {
+			resolved.Description = p.Description
+		}
+		if p.Model != "" {
+			resolved.Model = p.Model
+		}
+		if p.Prompt != "" {
+			resolved.Prompt = p.Prompt
+		}
+		if p.Temperature != nil {
+			resolved.Temperature = p.Temperature
+		}
+		resolved.Tools = resolved.Tools.Merge(p.Tools)
+		if len(p.Fallback) > 0 {
+			resolved.Fallback = p.Fallback
+		}
+		if len(p.Routes) > 0 {
+			resolved.Routes = p.Routes
+		}
+	}
+	resolved.Name = name
+	resolved.Extends = c.Profiles[name].Extends
+	return resolved, nil
+}
+
+// profileChain 返回从 name 开始沿 extends 向上的档案名称链
+func profileChain(profiles map[string]ProfileConfig, name string) ([]string, error) {
+	var chain []string
+	seen := make(map[string]bool)
+	for current := name; current != ""; current = profiles[current].Extends {
+		if seen[current] {
+			return nil, fmt.Errorf("配置档案的继承关系存在循环: %s", strings.Join(append(chain, current), " → "))
+		}
+		if _, ok := profiles[current]; !ok {
+			if current == name {
+				return nil, fmt.Errorf("配置档案不存在: %s", name)
+			}
+			return nil, fmt.Errorf("配置档案 %s 继承的档案不存在: %s", chain[len(chain)-1], current)
+		}
+		seen[current] = true
+		chain = append(chain, current)
+	}
+	return chain, nil
+}
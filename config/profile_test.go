@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	low, high := 0.2, 1.1
+	c := &Config{Profiles: map[string]ProfileConfig{
+		"base":       {Model: "gpt-4o", Temperature: &low, Tools: ToolsConfigData{RunCode: PermissionDeny, Clipboard: PermissionAllow}},
+		"review":     {Extends: "base", Prompt: "reviewer", Tools: ToolsConfigData{RunCode: PermissionAsk}},
+		"brainstorm": {Extends: "review", Model: "deepseek-chat", Temperature: &high},
+		"loop-a":     {Extends: "loop-b"},
+		"loop-b":     {Extends: "loop-a"},
+		"orphan":     {Extends: "missing"},
+	}}
+
+	p, err := c.Profile("brainstorm")
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if p.Name != "brainstorm" || p.Model != "deepseek-chat" || p.Prompt != "reviewer" || *p.Temperature != high {
+		t.Errorf("Profile(brainstorm) = %+v", p)
+	}
+	if p.Tools.RunCode != PermissionAsk || p.Tools.Clipboard != PermissionAllow || p.Tools.WriteFile != "" {
+		t.Errorf("Profile(brainstorm).Tools = %+v", p.Tools)
+	}
+
+	for _, name := range []string{"loop-a", "orphan", "unknown"} {
+		if _, err := c.Profile(name); err == nil {
+			t.Errorf("Profile(%s) should fail", name)
+		}
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `models:
+  - model: gpt-4o
+    api_key: sk-1234567890
+    url: https://api.openai.com/v1/chat/completions
+profiles:
+  base:
+    model: gpt-4o
+  review:
+    extends: base
+    temperature: 0.2
+    fallback: [gpt-4o, missing]
+    routes:
+      - model: gpt-4o
+        keywords: [review]
+      - model: gpt-4o
+      - model: gpt-4o
+        min_length: 10
+        max_length: 5
+  translate:
+    extends: nothing
+    model: claude
+    temperature: 3
+    tools:
+      run_code: never
+  a:
+    extends: b
+  b:
+    extends: a
+    colour: red
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	problems := Validate([]string{path})
+	want := []string{
+		"未知配置项 profiles.b.colour",
+		"profiles.translate.model 引用的模型 claude 不在 models 中",
+		"profiles.translate.extends 引用的档案 nothing 不存在",
+		"profiles.translate.temperature 的值 3 超出范围",
+		"profiles.translate.tools.run_code 的值 \"never\" 无效",
+		"profiles.a.extends 的继承关系存在循环",
+		"profiles.b.extends 的继承关系存在循环",
+		"profiles.review.fallback[1] 引用的模型 missing 不在 models 中",
+		"profiles.review.routes[1] 至少需要设置",
+		"profiles.review.routes[2] 的 min_length 10 大于 max_length 5",
+	}
+	if len(problems) != len(want) {
+		t.Errorf("Validate() returned %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for _, w := range want {
+		found := false
+		for _, p := range problems {
+			if strings.Contains(p.String(), w) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing problem %q in %v", w, problems)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	p := &ProfileConfig{
+		Fallback: []string{"deepseek-chat", "gpt-4o", "llama3"},
+		Routes: []RouteConfig{
+			{Model: "qwen-long", MinLength: 20},
+			{Model: "gpt-4o", Images: true},
+			{Model: "glm-4", Keywords: []string{"Translate", "翻译"}},
+			{Model: "never"},
+		},
+	}
+	tests := []struct {
+		name string
+		turn Turn
+		want string
+	}{
+		{"no route", Turn{Text: "hello"}, "gpt-4o,deepseek-chat,llama3"},
+		{"long prompt", Turn{Text: strings.Repeat("长", 20)}, "qwen-long,gpt-4o,deepseek-chat,llama3"},
+		{"images", Turn{Text: "看图", Attachments: 1, Images: 1}, "gpt-4o,deepseek-chat,llama3"},
+		{"keyword", Turn{Text: "Translate it"}, "glm-4,gpt-4o,deepseek-chat,llama3"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(p.Candidates("gpt-4o", tt.turn), ",")
+		if got != tt.want {
+			t.Errorf("%s: Candidates() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	var none *ProfileConfig
+	if got := none.Candidates("gpt-4o", Turn{Text: "hi"}); len(got) != 1 || got[0] != "gpt-4o" {
+		t.Errorf("nil profile Candidates() = %q", got)
+	}
+}
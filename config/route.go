@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Turn 一轮提问的特征，用于匹配路由规则
+type Turn struct {
+	Text        string // 展开文件引用后的提问内容
+	Attachments int    // 附加的文件与图片数量
+	Images      int    // 附加的图片数量
+}
+
+// HasCondition 判断规则是否设置了至少一个条件
+func (r RouteConfig) HasCondition() bool {
+	return r.MinLength > 0 || r.MaxLength > 0 || r.Attachments || r.Images || len(r.Keywords) > 0
+}
+
+// Match 判断提问是否满足规则的所有条件，没有设置条件的规则不会命中
+func (r RouteConfig) Match(t Turn) bool {
+	if !r.HasCondition() {
+		return false
+	}
+	length := utf8.RuneCountInString(t.Text)
+	if r.MinLength > 0 && length < r.MinLength {
+		return false
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		return false
+	}
+	if r.Attachments && t.Attachments == 0 {
+		return false
+	}
+	if r.Images && t.Images == 0 {
+		return false
+	}
+	if len(r.Keywords) > 0 {
+		text := strings.ToLower(t.Text)
+		for _, k := range r.Keywords {
+			if k != "" && strings.Contains(text, strings.ToLower(k)) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Candidates 返回一轮提问依次尝试的模型：路由规则选中的模型、当前模型，以及配置档案的备用模型，重复的模型只保留第一次。
+// 未使用配置档案（p 为 nil）时只返回当前模型。
+// param primary 为当前模型名称。
+// param t 为本轮提问的特征。
+//
+// return 按尝试顺序排列的模型名称。
+func (p *ProfileConfig) Candidates(primary string, t Turn) []string {
+	candidates := []string{primary}
+	if p == nil {
+		return candidates
+	}
+	for _, r := range p.Routes {
+		if r.Match(t) {
+			candidates = append([]string{r.Model}, candidates...)
+			break
+		}
+	}
+	candidates = append(candidates, p.Fallback...)
+
+	seen := make(map[string]bool, len(candidates))
+	unique := candidates[:0]
+	for _, name := range candidates {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
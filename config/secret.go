@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sparrow-cli/credential"
+	"sparrow-cli/env"
+	"strings"
+	"time"
+)
+
+// secretCommandTimeout cmd: 引用执行命令的超时时间
+const secretCommandTimeout = 10 * time.Second
+
+// envRefPattern 匹配 ${VAR} 与 ${VAR:-默认值}
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ResolveSecret 解析配置值中的引用，结果只保存在内存中，不会写回配置文件：
+//   - file:<路径> 读取密钥文件内容（去除首尾空白），路径支持 ~ 与 ${VAR}
+//   - cmd:<命令> 使用 sh 执行命令并取其标准输出，例如 cmd:pass show openai
+//   - 其他值中的 ${VAR} 或 ${VAR:-默认值} 替换为环境变量
+//
+// 只用于来自家目录配置的值：项目级配置中的引用在校验时即被拒绝，加载时也不会对来自项目配置的值调用本函数。
+//
+// param value 为配置中的原始值。
+//
+// return 解析后的值和可能的错误。引用的环境变量未设置、文件不可读或命令执行失败时返回错误。
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "file:"):
+		path, err := ExpandEnv(strings.TrimSpace(strings.TrimPrefix(value, "file:")))
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[2:])
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取密钥文件失败 %s: %w", path, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	case strings.HasPrefix(value, "cmd:"):
+		command := strings.TrimSpace(strings.TrimPrefix(value, "cmd:"))
+		ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		cmd.Stdin = os.Stdin // 允许 pass、gpg 等工具询问口令
+		if err := cmd.Run(); err != nil {
+			// 不输出命令的标准输出，避免泄露部分密钥
+			return "", fmt.Errorf("执行密钥命令失败 %q: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(stdout.String()), nil
+
+	default:
+		return ExpandEnv(value)
+	}
+}
+
+// ExpandEnv 替换值中的 ${VAR} 与 ${VAR:-默认值}，未设置且没有默认值的变量返回错误。
+// 不处理 $VAR 形式，以免误伤本身包含 $ 的密钥。
+func ExpandEnv(value string) (string, error) {
+	var missing []string
+	expanded := envRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		m := envRefPattern.FindStringSubmatch(ref)
+		if v, ok := os.LookupEnv(m[1]); ok && v != "" {
+			return v
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		missing = append(missing, m[1])
+		return ""
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("环境变量未设置: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// resolveModels 解析所有模型配置中的引用，解析失败的字段保持为空并返回错误列表。
+// 只解析来自家目录配置的值，来自项目配置的值按原样使用，其中的引用不会被解析，
+// 以免环境变量、命令输出或本机文件的内容被填进项目指定的地址与请求头
+func resolveModels(models []ModelConfig) []error {
+	var errs []error
+	for i := range models {
+		m := &models[i]
+
+		apiKey, err := m.resolve("api_key", m.ApiKey, ResolveSecret)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("模型 %s 的 api_key 解析失败: %w", m.Model, err))
+		}
+		m.ApiKey = apiKey
+
+		url, err := m.resolve("url", m.URL, ExpandEnv)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("模型 %s 的 url 解析失败: %w", m.Model, err))
+		}
+		m.URL = url
+
+		// 请求头可能包含密钥，例如 Azure 风格网关的 api-key
+		if len(m.Headers) > 0 {
+			headers := make(map[string]string, len(m.Headers))
+			for key, value := range m.Headers {
+				resolved, err := m.resolve("headers."+key, value, ResolveSecret)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("模型 %s 的请求头 %s 解析失败: %w", m.Model, key, err))
+				}
+				headers[key] = resolved
+			}
+			m.Headers = headers
+		}
+
+		// 代理地址与证书路径支持 ${VAR} 引用，name 与 refFields 中的字段路径一致
+		for _, field := range []struct {
+			name  string
+			value *string
+		}{
+			{"transport.proxy", &m.Transport.Proxy},
+			{"transport.ca_file", &m.Transport.CAFile},
+			{"transport.cert_file", &m.Transport.CertFile},
+			{"transport.key_file", &m.Transport.KeyFile},
+		} {
+			expanded, err := m.resolve(field.name, *field.value, ExpandEnv)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("模型 %s 的 %s 解析失败: %w", m.Model, field.name, err))
+			}
+			*field.value = expanded
+		}
+	}
+	return errs
+}
+
+// resolve 解析字段的值：来自家目录配置时调用 fn 解析引用，来自项目配置时按原样返回，
+// 项目配置中的值包含引用时返回空值与错误（校验时已拒绝这类引用，这里只是兜底）
+func (m *ModelConfig) resolve(field, value string, fn func(string) (string, error)) (string, error) {
+	source := m.sources[field]
+	if !isProjectLayer(source) {
+		return fn(value)
+	}
+	if isSecretRef(value) {
+		return "", fmt.Errorf("来自项目配置 %s，不解析其中的引用", source)
+	}
+	return value, nil
+}
+
+// credentialStore 已解锁的凭据存储，由 loadLock 保护
+var credentialStore *credential.Store
+
+// resolveCredentials 从加密凭据存储中读取模型引用的密钥，只有存在引用时才会要求输入口令
+func resolveCredentials(models []ModelConfig) []error {
+	referenced := false
+	for _, m := range models {
+		if m.Credential != "" {
+			referenced = true
+			break
+		}
+	}
+	if !referenced {
+		return nil
+	}
+
+	// 重新加载配置时使用已解锁的凭据存储重新读取，不再询问口令
+	var err error
+	if credentialStore != nil {
+		credentialStore, err = credentialStore.Reload()
+	} else {
+		credentialStore, err = credential.Unlock(credential.DefaultPath(env.SparrowCliHome))
+	}
+	if err != nil {
+		credentialStore = nil
+		return []error{fmt.Errorf("解锁凭据存储失败: %w", err)}
+	}
+	store := credentialStore
+
+	var errs []error
+	for i := range models {
+		m := &models[i]
+		if m.Credential == "" {
+			continue
+		}
+		secret, ok := store.Get(m.Credential)
+		if !ok {
+			errs = append(errs, fmt.Errorf("模型 %s 引用的凭据不存在: %s", m.Model, m.Credential))
+			continue
+		}
+		m.ApiKey = secret
+	}
+	return errs
+}
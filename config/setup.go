@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sparrow-cli/file"
+	"text/template"
+)
+
+// initialConfigTemplate 配置向导生成的配置文件模板，带有各配置项的说明
+var initialConfigTemplate = template.Must(template.New("config").Funcs(template.FuncMap{
+	"quote":   yamlQuote,
+	"version": func() int { return CurrentVersion },
+}).Parse(`# sparrow-cli 配置文件，由 sparrow-cli setup 生成
+# 运行 sparrow-cli config validate 校验配置，sparrow-cli config show 查看合并后的有效配置
+
+# 配置文件版本，由 sparrow-cli 自动维护
+version: {{version}}
+
+# 模型列表，第一个模型为启动时的默认模型
+models:
+  - model: {{quote .Model}}
+    # 服务商: openai, deepseek, qwen, zhipu, ollama, azure
+    provider: {{quote .Provider}}
+    url: {{quote .URL}}
+{{- if .Deployment}}
+    # Azure 部署名称与接口版本
+    deployment: {{quote .Deployment}}
+    api_version: {{quote .APIVersion}}
+{{- end}}
+{{- if .Credential}}
+    # 密钥保存在加密凭据存储中，使用 sparrow-cli auth 管理
+    credential: {{quote .Credential}}
+{{- else if .ApiKey}}
+    # 支持 ${环境变量}、file:<路径>、cmd:<命令> 引用，避免明文保存密钥
+    api_key: {{quote .ApiKey}}
+{{- end}}
+    # 网络传输设置，按需取消注释
+    # transport:
+    #   connect_timeout: 30s      # 建立连接的超时时间
+    #   first_byte_timeout: 3m    # 等待响应的超时时间
+    #   idle_timeout: 2m          # 流式输出中两次收到数据的最长间隔
+    #   proxy: socks5://127.0.0.1:1080
+    #   ca_file: /path/to/ca.pem
+    # headers:
+    #   X-Custom-Header: value
+
+# 默认系统提示词名称，对应提示词目录中的文件，为空时使用内置提示词
+default_prompt: ""
+
+logger:
+  level: info      # 日志级别: debug, info, warn, error
+  max_age: 30      # 日志文件保留天数
+  max_size: 10     # 单个日志文件最大大小(MB)
+  max_backups: 5   # 日志备份文件最大数量
+  compress: false  # 是否压缩日志备份
+
+tools:
+  run_code: ask      # 运行代码块: ask, allow, deny
+  write_file: allow  # 将代码块写入文件
+  clipboard: allow   # 复制到剪贴板
+`))
+
+// RenderInitialConfig 生成带注释的初始配置文件内容。
+// param m 为配置向导收集的模型配置。
+//
+// return 配置文件内容和可能的错误。
+func RenderInitialConfig(m ModelConfig) ([]byte, error) {
+	if m.Provider == "" {
+		m.Provider = "openai"
+	}
+	var buf bytes.Buffer
+	if err := initialConfigTemplate.Execute(&buf, m); err != nil {
+		return nil, fmt.Errorf("生成配置文件失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteConfigFile 将配置内容写入文件，先写入临时文件再替换以免损坏原文件。
+// 配置中可能含有密钥，文件权限为 0600。
+// param path 为配置文件路径。
+// param data 为配置内容。
+//
+// return 可能的错误。
+func WriteConfigFile(path string, data []byte) error {
+	if err := file.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("写入配置文件失败 %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换配置文件失败 %s: %w", path, err)
+	}
+	return nil
+}
+
+// yamlQuote 将字符串转换为 YAML 双引号字符串，JSON 字符串同时是合法的 YAML
+func yamlQuote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderInitialConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		model ModelConfig
+		want  string
+	}{
+		{
+			name:  "api key",
+			model: ModelConfig{Model: "gpt-4o", ApiKey: `sk-"quoted"`, URL: "https://api.openai.com/v1/chat/completions"},
+			want:  `api_key: "sk-\"quoted\""`,
+		},
+		{
+			name:  "credential",
+			model: ModelConfig{Model: "deepseek-chat", Provider: "deepseek", Credential: "deepseek", URL: "https://api.deepseek.com/chat/completions"},
+			want:  `credential: "deepseek"`,
+		},
+		{
+			name:  "ollama without key",
+			model: ModelConfig{Model: "llama3", Provider: "ollama", URL: "http://localhost:11434/v1/chat/completions"},
+			want:  `provider: "ollama"`,
+		},
+		{
+			name:  "azure deployment",
+			model: ModelConfig{Model: "gpt-4o", Provider: "azure", ApiKey: "key", URL: "https://demo.openai.azure.com", Deployment: "chat", APIVersion: "2024-10-21"},
+			want:  "deployment: \"chat\"\n    api_version: \"2024-10-21\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := RenderInitialConfig(tt.model)
+			if err != nil {
+				t.Fatalf("RenderInitialConfig() error = %v", err)
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("RenderInitialConfig() missing %q:\n%s", tt.want, data)
+			}
+
+			path := filepath.Join(t.TempDir(), "config", "config.yaml")
+			if err := WriteConfigFile(path, data); err != nil {
+				t.Fatalf("WriteConfigFile() error = %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("config file mode = %v, want 0600", info.Mode().Perm())
+			}
+			if problems := Validate([]string{path}); len(problems) != 0 {
+				t.Errorf("generated config is invalid: %v", problems)
+			}
+		})
+	}
+}
+
+func TestRenderInitialConfigInvalidURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"azure placeholder", "https://<资源名称>.openai.azure.com", "请替换其中的占位符"},
+		{"missing scheme", "api.openai.com/v1/chat/completions", "必须以 http:// 或 https:// 开头"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := RenderInitialConfig(ModelConfig{Model: "gpt-4o", ApiKey: "key", URL: tt.url})
+			if err != nil {
+				t.Fatalf("RenderInitialConfig() error = %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			problems := ValidateData(path, data)
+			if len(problems) != 1 || !strings.HasPrefix(problems[0].Message, "models[0].url ") || !strings.Contains(problems[0].Message, tt.want) {
+				t.Errorf("ValidateData() = %v, want a models[0].url problem containing %q", problems, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// permissionRanks 工具权限从宽到严的顺序
+var permissionRanks = map[Permission]int{PermissionAllow: 0, PermissionAsk: 1, PermissionDeny: 2}
+
+// isProjectLayer 判断配置文件是否为项目级配置。项目配置随代码仓库分发，任何克隆下来的仓库都可以带上一份，
+// 因此不信任其内容：不能通过 ${VAR}、cmd: 与 file: 引用读取环境变量、执行命令或读取本机文件；
+// 不能修改从其他配置文件或凭据存储获取密钥的模型的 url、headers 与 transport，以免密钥被发往项目指定的地址；
+// 地址由项目配置设置的模型不会成为默认模型，只有明确选择时才使用；也不能放宽工具权限，只能收紧
+func isProjectLayer(path string) bool {
+	return filepath.Base(path) == ProjectConfigFileName
+}
+
+// refFields 模型中会解析引用的字段，transport 只有路径与代理地址支持引用
+var refFields = []string{"api_key", "url", "transport.proxy", "transport.ca_file", "transport.cert_file", "transport.key_file"}
+
+// isSecretRef 判断配置值是否包含 ${VAR}、cmd: 或 file: 引用
+func isSecretRef(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "cmd:") || strings.HasPrefix(value, "file:") || envRefPattern.MatchString(value)
+}
+
+// checkSecretRefs 检查项目配置中 api_key、url、请求头与传输设置的 ${VAR}、cmd:、file: 引用。
+// 加载配置时会解析这些引用，若允许项目配置使用，在克隆的仓库中运行任意 sparrow-cli 命令都会执行仓库指定的命令，
+// 或把环境变量中的密钥填进仓库指定的地址与请求头
+func checkSecretRefs(path string, root *yaml.Node) []Problem {
+	models := child(root, "models")
+	if models == nil || models.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var problems []Problem
+	for i, item := range models.Content {
+		values := modelRefValues(item)
+		for _, key := range sortedNodeKeys(values) {
+			if n := values[key]; n.Kind == yaml.ScalarNode && isSecretRef(n.Value) {
+				problems = append(problems, Problem{File: path, Line: n.Line, Column: n.Column,
+					Message: fmt.Sprintf("models[%d].%s: 项目配置不能使用 ${VAR}、cmd: 或 file: 引用，请直接填写或在家目录配置中设置", i, key)})
+			}
+		}
+	}
+	return problems
+}
+
+// fieldSources 返回模型中会解析引用的字段的来源文件
+func fieldSources(item *yaml.Node) map[string]string {
+	values := modelRefValues(item)
+	sources := make(map[string]string, len(values))
+	for key, n := range values {
+		sources[key] = n.LineComment
+	}
+	return sources
+}
+
+// modelRefValues 返回模型中会解析引用的值，键为字段路径，例如 headers.X-Token
+func modelRefValues(item *yaml.Node) map[string]*yaml.Node {
+	values := make(map[string]*yaml.Node)
+	for _, key := range refFields {
+		n := item
+		for _, part := range strings.Split(key, ".") {
+			n = child(n, part)
+		}
+		if n != nil {
+			values[key] = n
+		}
+	}
+	if headers := child(item, "headers"); headers != nil && headers.Kind == yaml.MappingNode {
+		for j := 0; j+1 < len(headers.Content); j += 2 {
+			values["headers."+headers.Content[j].Value] = headers.Content[j+1]
+		}
+	}
+	return values
+}
+
+// sortedNodeKeys 返回排序后的键，使问题的顺序稳定
+func sortedNodeKeys(m map[string]*yaml.Node) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// projectEndpoint 返回模型中来自项目配置的 url、headers 或 transport 节点及其字段名，都不来自项目配置时返回 nil
+func projectEndpoint(item *yaml.Node) (*yaml.Node, string) {
+	for _, key := range []string{"url", "headers", "transport"} {
+		for _, leaf := range leaves(child(item, key)) {
+			if isProjectLayer(leaf.LineComment) {
+				return leaf, key
+			}
+		}
+	}
+	return nil, ""
+}
+
+// projectEndpointModels 返回合并后的配置中地址、请求头或传输设置由项目配置设置的模型名称
+func projectEndpointModels(merged *yaml.Node) map[string]bool {
+	result := make(map[string]bool)
+	models := child(merged, "models")
+	if models == nil || models.Kind != yaml.SequenceNode {
+		return result
+	}
+	for _, item := range models.Content {
+		if endpoint, _ := projectEndpoint(item); endpoint != nil {
+			result[modelName(item)] = true
+		}
+	}
+	return result
+}
+
+// checkModelEndpoint 检查合并后的模型：url、headers 或 transport 来自项目配置时，
+// 模型的 api_key 与请求头必须来自同一个项目配置，且不能使用凭据存储中的凭据
+func checkModelEndpoint(item *yaml.Node, prefix string) []Problem {
+	endpoint, endpointKey := projectEndpoint(item)
+	if endpoint == nil {
+		return nil
+	}
+	source := endpoint.LineComment
+
+	var secrets []string
+	if n := child(item, "credential"); n != nil && n.Value != "" {
+		secrets = append(secrets, "凭据存储中的凭据 "+n.Value)
+	}
+	if n := child(item, "api_key"); n != nil && n.Value != "" && n.LineComment != source {
+		secrets = append(secrets, "api_key（来自 "+n.LineComment+"）")
+	}
+	if headers := child(item, "headers"); headers != nil && headers.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(headers.Content); i += 2 {
+			if value := headers.Content[i+1]; value.Value != "" && value.LineComment != source {
+				secrets = append(secrets, fmt.Sprintf("请求头 %s（来自 %s）", headers.Content[i].Value, value.LineComment))
+			}
+		}
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	return []Problem{problemAt(endpoint, fmt.Sprintf("%s.%s 由项目配置设置，但模型使用了%s；"+
+		"为避免密钥被发往项目指定的地址，请在项目配置中为该模型设置 api_key，或在家目录配置中修改", prefix, endpointKey, secrets[0]))}
+}
+
+// leaves 返回节点下的所有标量叶子节点
+func leaves(n *yaml.Node) []*yaml.Node {
+	if n == nil {
+		return nil
+	}
+	if n.Kind == yaml.ScalarNode {
+		return []*yaml.Node{n}
+	}
+	var result []*yaml.Node
+	for i, c := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		result = append(result, leaves(c)...)
+	}
+	return result
+}
+
+// checkProjectTools 检查项目配置设置的工具权限（包括配置档案中的工具权限）是否比其他配置文件更宽松。
+// 项目配置中档案的工具权限与其他配置文件中同名档案的权限比较，档案未设置时与全局的 tools 比较。
+// param paths 为配置文件路径，按优先级从低到高排列。
+// param merged 为合并后的配置。
+// param edits 为修改后尚未写入的文件内容。
+//
+// return 放宽了工具权限的问题。
+func checkProjectTools(paths []string, merged *yaml.Node, edits map[string][]byte) []Problem {
+	var trusted []string
+	for _, path := range paths {
+		if !isProjectLayer(path) {
+			trusted = append(trusted, path)
+		}
+	}
+	if len(trusted) == len(paths) {
+		return nil
+	}
+	base, err := mergeEditedLayers(trusted, edits)
+	if err != nil {
+		return nil // 其他配置文件的问题由逐个文件校验报告
+	}
+
+	problems := checkToolsLooser(child(merged, "tools"), "tools", func(key string) Permission {
+		return trustedPermission(base, key)
+	})
+	profiles := child(merged, "profiles")
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		return problems
+	}
+	for i := 0; i+1 < len(profiles.Content); i += 2 {
+		name := profiles.Content[i].Value
+		baseTools := child(child(child(base, "profiles"), name), "tools")
+		problems = append(problems, checkToolsLooser(child(profiles.Content[i+1], "tools"), "profiles."+name+".tools", func(key string) Permission {
+			if n := child(baseTools, key); n != nil && n.Value != "" {
+				return Permission(n.Value)
+			}
+			return trustedPermission(base, key)
+		})...)
+	}
+	return problems
+}
+
+// checkToolsLooser 检查 tools 映射中来自项目配置的权限是否比 baseline 返回的权限更宽松
+func checkToolsLooser(tools *yaml.Node, prefix string, baseline func(key string) Permission) []Problem {
+	if tools == nil || tools.Kind != yaml.MappingNode {
+		return nil
+	}
+	var problems []Problem
+	for i := 0; i+1 < len(tools.Content); i += 2 {
+		key, value := tools.Content[i].Value, tools.Content[i+1]
+		if !isProjectLayer(value.LineComment) {
+			continue
+		}
+		rank, ok := permissionRanks[Permission(value.Value)]
+		base := baseline(key)
+		baseRank, baseOK := permissionRanks[base]
+		if ok && baseOK && rank < baseRank {
+			problems = append(problems, problemAt(value, fmt.Sprintf("项目配置不能放宽工具权限: %s.%s 为 %s，其他配置中为 %s，项目配置只能收紧权限",
+				prefix, key, value.Value, base)))
+		}
+	}
+	return problems
+}
+
+// trustedPermission 返回其他配置文件中全局 tools 的权限，未设置时返回默认权限
+func trustedPermission(base *yaml.Node, key string) Permission {
+	if n := child(child(base, "tools"), key); n != nil && n.Value != "" {
+		return Permission(n.Value)
+	}
+	return DefaultTools.Get(key)
+}
@@ -0,0 +1,618 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"sparrow-cli/global"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem 配置校验发现的一个问题
+type Problem struct {
+	File    string // 问题所在的配置文件，无法确定时为空
+	Line    int    // 行号，从 1 开始，无法确定时为 0
+	Column  int    // 列号，从 1 开始，无法确定时为 0
+	Message string // 问题描述
+}
+
+// String 按 文件:行:列: 描述 的格式输出问题
+func (p Problem) String() string {
+	location := p.File
+	if location == "" {
+		location = "<配置>"
+	}
+	if p.Line > 0 {
+		location += ":" + strconv.Itoa(p.Line)
+		if p.Column > 0 {
+			location += ":" + strconv.Itoa(p.Column)
+		}
+	}
+	return location + ": " + p.Message
+}
+
+// ValidationError 配置校验失败，包含发现的全部问题
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "配置校验失败，共 %d 个问题:", len(e.Problems))
+	for _, p := range e.Problems {
+		sb.WriteString("\n  " + p.String())
+	}
+	return sb.String()
+}
+
+// yamlLinePattern 匹配 yaml 错误信息中的行号
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// apiVersionPattern 匹配 Azure OpenAI 的接口版本
+var apiVersionPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(-preview)?$`)
+
+// logLevels 日志配置支持的级别
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// numericRanges 数值配置项的取值范围，max 为 0 表示不限制上限
+var numericRanges = []struct {
+	section, key string
+	min, max     int
+}{
+	{"logger", "max_age", 0, 3650},
+	{"logger", "max_size", 0, 10240},
+	{"logger", "max_backups", 0, 1000},
+	{"history", "max_entries", 0, 0},
+	{"history", "max_entry_bytes", 0, 0},
+	{"attach", "max_file_bytes", 0, 0},
+	{"attach", "max_total_bytes", 0, 0},
+	{"attach", "max_files", 0, 0},
+	{"attach", "max_image_bytes", 0, 0},
+}
+
+// Validate 校验配置文件，检查 YAML 语法、未知配置项、类型错误，以及合并后配置的必填项、URL 格式、
+// 服务商名称与数值范围。
+// param paths 为配置文件路径，按优先级从低到高排列，不存在的文件会被跳过。
+//
+// return 发现的全部问题，配置有效时为空。
+func Validate(paths []string) []Problem {
+	return validateLayers(paths, nil)
+}
+
+// ValidateData 校验配置文件修改后的内容，用于在写入前检查修改结果。
+// path 是当前参与合并的配置文件时（家目录配置、当前目录向上找到的项目配置或当前目录中新建的项目配置），
+// 以修改后的内容代替该文件与其他配置文件合并后校验，只覆盖部分配置项的项目配置同样可以通过校验；
+// 其他文件单独校验。其他配置文件中修改前已经存在的问题不会被报告，以免妨碍修复。
+// param path 为配置文件路径。
+// param data 为配置文件内容。
+//
+// return 发现的全部问题，内容有效时为空。
+func ValidateData(path string, data []byte) []Problem {
+	paths := layerPaths(path)
+	if paths == nil {
+		return validateLayers([]string{path}, map[string][]byte{path: data})
+	}
+
+	existing := make(map[string]bool)
+	for _, p := range Validate(paths) {
+		existing[p.String()] = true
+	}
+	var problems []Problem
+	for _, p := range validateLayers(paths, map[string][]byte{path: data}) {
+		if filepath.Clean(p.File) == filepath.Clean(path) || !existing[p.String()] {
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// validateLayers 校验并合并配置文件，edits 中的文件使用修改后的内容代替磁盘上的内容
+func validateLayers(paths []string, edits map[string][]byte) []Problem {
+	var problems []Problem
+	parsed := true
+	for _, path := range paths {
+		data, ok, err := readLayer(path, edits)
+		if err != nil {
+			problems = append(problems, Problem{File: path, Message: err.Error()})
+			parsed = false
+			continue
+		}
+		if !ok {
+			continue
+		}
+		fileProblems, ok := validateContent(path, data)
+		problems = append(problems, fileProblems...)
+		parsed = parsed && ok
+	}
+	// 存在语法错误时无法合并，只报告逐个文件的问题
+	if !parsed {
+		return problems
+	}
+
+	merged, err := mergeEditedLayers(paths, edits)
+	if err != nil {
+		// 版本过高等问题已在逐个文件校验时报告
+		if len(problems) == 0 {
+			problems = append(problems, Problem{Message: err.Error()})
+		}
+		return problems
+	}
+	problems = append(problems, validateMerged(merged)...)
+	return append(problems, checkProjectTools(paths, merged, edits)...)
+}
+
+// validateContent 校验配置文件内容的语法、配置项名称与类型
+func validateContent(path string, data []byte) ([]Problem, bool) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Problem{yamlProblem(path, err.Error())}, false
+	}
+	if len(doc.Content) == 0 {
+		return nil, true
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []Problem{{File: path, Line: root.Line, Column: root.Column, Message: "配置文件的顶层必须是映射"}}, false
+	}
+
+	problems := checkKeys(path, root, reflect.TypeOf(ProjectConfig{}), "")
+	if isProjectLayer(path) {
+		problems = append(problems, checkSecretRefs(path, root)...)
+	}
+	if n := child(root, "version"); n != nil {
+		if version, err := configVersion(root); err == nil && version > CurrentVersion {
+			problems = append(problems, Problem{File: path, Line: n.Line, Column: n.Column,
+				Message: fmt.Sprintf("配置文件版本 %d 高于当前程序支持的版本 %d，请升级 sparrow-cli", version, CurrentVersion)})
+		}
+	}
+	if err := root.Decode(&ProjectConfig{}); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				problems = append(problems, yamlProblem(path, msg))
+			}
+		} else {
+			problems = append(problems, yamlProblem(path, err.Error()))
+		}
+	}
+	return problems, true
+}
+
+// yamlProblem 将 yaml 错误信息转换为带行号的问题
+func yamlProblem(path, msg string) Problem {
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Problem{File: path, Line: line, Message: m[2]}
+	}
+	return Problem{File: path, Message: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// checkKeys 对照配置结构体检查未知的配置项。
+// param path 为配置文件路径。
+// param n 为当前节点。
+// param t 为当前节点对应的 Go 类型。
+// param prefix 为当前节点的配置路径，例如 models[0]。
+//
+// return 发现的未知配置项。
+func checkKeys(path string, n *yaml.Node, t reflect.Type, prefix string) []Problem {
+	var problems []Problem
+	switch t.Kind() {
+	case reflect.Pointer:
+		return checkKeys(path, n, t.Elem(), prefix)
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return nil // 类型错误由解码阶段报告
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			name := joinPath(prefix, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, Problem{
+					File:    path,
+					Line:    key.Line,
+					Column:  key.Column,
+					Message: fmt.Sprintf("未知配置项 %s，可用的配置项: %s", name, strings.Join(sortedKeys(fields), ", ")),
+				})
+				continue
+			}
+			problems = append(problems, checkKeys(path, value, field, name)...)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			problems = append(problems, checkKeys(path, n.Content[i+1], t.Elem(), joinPath(prefix, n.Content[i].Value))...)
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range n.Content {
+			problems = append(problems, checkKeys(path, item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+	}
+	return problems
+}
+
+// yamlFields 返回结构体的 yaml 字段名与字段类型
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// sortedKeys 返回排序后的字段名
+func sortedKeys(fields map[string]reflect.Type) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinPath 拼接配置路径
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// validateMerged 校验合并后的配置，问题定位到取值所在的原始文件
+func validateMerged(root *yaml.Node) []Problem {
+	var problems []Problem
+
+	if models := child(root, "models"); models != nil && models.Kind == yaml.SequenceNode {
+		seen := make(map[string]*yaml.Node)
+		for i, item := range models.Content {
+			if item.Kind == yaml.MappingNode {
+				problems = append(problems, validateModel(item, fmt.Sprintf("models[%d]", i), seen)...)
+			}
+		}
+	}
+
+	if level := child(child(root, "logger"), "level"); level != nil && level.Value != "" && !contains(logLevels, level.Value) {
+		problems = append(problems, problemAt(level, fmt.Sprintf("logger.level 的值 %q 无效，可选: %s", level.Value, strings.Join(logLevels, ", "))))
+	}
+
+	for _, r := range numericRanges {
+		n := child(child(root, r.section), r.key)
+		if n == nil || n.Kind != yaml.ScalarNode {
+			continue
+		}
+		v, err := strconv.Atoi(n.Value)
+		if err != nil {
+			continue // 类型错误已在逐个文件校验时报告
+		}
+		if v < r.min || (r.max > 0 && v > r.max) {
+			rangeDesc := fmt.Sprintf("不能小于 %d", r.min)
+			if r.max > 0 {
+				rangeDesc = fmt.Sprintf("应在 %d 到 %d 之间", r.min, r.max)
+			}
+			problems = append(problems, problemAt(n, fmt.Sprintf("%s.%s 的值 %d 超出范围，%s", r.section, r.key, v, rangeDesc)))
+		}
+	}
+
+	problems = append(problems, validatePermissions(child(root, "tools"), "tools")...)
+
+	if profiles := child(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		problems = append(problems, validateProfiles(profiles, child(root, "models"))...)
+	}
+	return problems
+}
+
+// validatePermissions 校验工具权限的取值
+func validatePermissions(tools *yaml.Node, prefix string) []Problem {
+	if tools == nil || tools.Kind != yaml.MappingNode {
+		return nil
+	}
+	var problems []Problem
+	permissions := []string{string(PermissionAsk), string(PermissionAllow), string(PermissionDeny)}
+	for i := 0; i+1 < len(tools.Content); i += 2 {
+		value := tools.Content[i+1]
+		if value.Kind == yaml.ScalarNode && value.Value != "" && !contains(permissions, value.Value) {
+			problems = append(problems, problemAt(value, fmt.Sprintf("%s.%s 的值 %q 无效，可选: %s",
+				prefix, tools.Content[i].Value, value.Value, strings.Join(permissions, ", "))))
+		}
+	}
+	return problems
+}
+
+// validateProfiles 校验配置档案引用的模型、继承的档案、温度与工具权限
+func validateProfiles(profiles, models *yaml.Node) []Problem {
+	var problems []Problem
+	modelNames := make(map[string]bool)
+	if models != nil {
+		for _, item := range models.Content {
+			modelNames[modelName(item)] = true
+		}
+	}
+
+	extends := make(map[string]string)
+	for i := 0; i+1 < len(profiles.Content); i += 2 {
+		if p := profiles.Content[i+1]; p.Kind == yaml.MappingNode {
+			if e := child(p, "extends"); e != nil {
+				extends[profiles.Content[i].Value] = e.Value
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(profiles.Content); i += 2 {
+		name, p := profiles.Content[i].Value, profiles.Content[i+1]
+		if p.Kind != yaml.MappingNode {
+			continue
+		}
+		prefix := "profiles." + name
+
+		if m := child(p, "model"); m != nil && m.Value != "" && !modelNames[m.Value] {
+			problems = append(problems, problemAt(m, fmt.Sprintf("%s.model 引用的模型 %s 不在 models 中", prefix, m.Value)))
+		}
+		if e := child(p, "extends"); e != nil && e.Value != "" {
+			if child(profiles, e.Value) == nil {
+				problems = append(problems, problemAt(e, fmt.Sprintf("%s.extends 引用的档案 %s 不存在", prefix, e.Value)))
+			} else if inCycle(extends, name) {
+				problems = append(problems, problemAt(e, fmt.Sprintf("%s.extends 的继承关系存在循环", prefix)))
+			}
+		}
+		if t := child(p, "temperature"); t != nil && t.Kind == yaml.ScalarNode && !isNull(t) {
+			if v, err := strconv.ParseFloat(t.Value, 64); err == nil && (v < 0 || v > 2) {
+				problems = append(problems, problemAt(t, fmt.Sprintf("%s.temperature 的值 %g 超出范围，应在 0 到 2 之间", prefix, v)))
+			}
+		}
+		problems = append(problems, validatePermissions(child(p, "tools"), prefix+".tools")...)
+
+		if fallback := child(p, "fallback"); fallback != nil && fallback.Kind == yaml.SequenceNode {
+			for j, m := range fallback.Content {
+				if m.Kind == yaml.ScalarNode && !modelNames[m.Value] {
+					problems = append(problems, problemAt(m, fmt.Sprintf("%s.fallback[%d] 引用的模型 %s 不在 models 中", prefix, j, m.Value)))
+				}
+			}
+		}
+		if routes := child(p, "routes"); routes != nil && routes.Kind == yaml.SequenceNode {
+			for j, r := range routes.Content {
+				if r.Kind == yaml.MappingNode {
+					problems = append(problems, validateRoute(r, fmt.Sprintf("%s.routes[%d]", prefix, j), modelNames)...)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// validateRoute 校验路由规则的模型与条件
+func validateRoute(r *yaml.Node, prefix string, modelNames map[string]bool) []Problem {
+	var problems []Problem
+	if m := child(r, "model"); m == nil || m.Value == "" {
+		problems = append(problems, problemAt(r, prefix+".model 不能为空"))
+	} else if !modelNames[m.Value] {
+		problems = append(problems, problemAt(m, fmt.Sprintf("%s.model 引用的模型 %s 不在 models 中", prefix, m.Value)))
+	}
+
+	var route RouteConfig
+	if err := r.Decode(&route); err != nil {
+		return problems // 类型错误已在逐个文件校验时报告
+	}
+	if !route.HasCondition() {
+		problems = append(problems, problemAt(r, prefix+" 至少需要设置 min_length、max_length、attachments、images 或 keywords 中的一个条件"))
+	}
+	if route.MinLength < 0 || route.MaxLength < 0 {
+		problems = append(problems, problemAt(r, prefix+" 的 min_length 与 max_length 不能为负数"))
+	} else if route.MaxLength > 0 && route.MinLength > route.MaxLength {
+		problems = append(problems, problemAt(r, fmt.Sprintf("%s 的 min_length %d 大于 max_length %d", prefix, route.MinLength, route.MaxLength)))
+	}
+	return problems
+}
+
+// inCycle 判断从 name 出发沿 extends 能否回到 name
+func inCycle(extends map[string]string, name string) bool {
+	seen := make(map[string]bool)
+	for current := extends[name]; current != ""; current = extends[current] {
+		if current == name {
+			return true
+		}
+		if seen[current] {
+			return false
+		}
+		seen[current] = true
+	}
+	return false
+}
+
+// validateModel 校验单个模型配置的必填项、服务商与 URL 格式
+func validateModel(item *yaml.Node, prefix string, seen map[string]*yaml.Node) []Problem {
+	var problems []Problem
+
+	name := child(item, "model")
+	switch {
+	case name == nil || strings.TrimSpace(name.Value) == "":
+		problems = append(problems, problemAt(item, prefix+".model 不能为空"))
+	case seen[name.Value] != nil:
+		first := seen[name.Value]
+		problems = append(problems, problemAt(name, fmt.Sprintf("模型名称 %s 重复，首次定义于 %s:%d", name.Value, first.LineComment, first.Line)))
+	default:
+		seen[name.Value] = name
+	}
+
+	provider := global.ProviderOpenAI
+	if n := child(item, "provider"); n != nil && n.Value != "" {
+		provider = global.Provider(n.Value)
+		if !isKnownProvider(provider) {
+			problems = append(problems, problemAt(n, fmt.Sprintf("%s.provider 的值 %q 无效，可选: %s", prefix, n.Value, knownProviderNames())))
+		}
+	}
+
+	if n := child(item, "url"); n == nil || strings.TrimSpace(n.Value) == "" {
+		problems = append(problems, problemAt(item, prefix+".url 不能为空"))
+	} else if err := checkURL(n.Value); err != nil {
+		problems = append(problems, problemAt(n, fmt.Sprintf("%s.url %v", prefix, err)))
+	}
+
+	if n := child(item, "api_version"); n != nil && n.Value != "" && !apiVersionPattern.MatchString(n.Value) {
+		problems = append(problems, problemAt(n, fmt.Sprintf("%s.api_version 的值 %q 无效，格式应为 2024-10-21 或 2025-01-01-preview", prefix, n.Value)))
+	}
+	for _, key := range []string{"deployment", "api_version"} {
+		if n := child(item, key); n != nil && n.Value != "" && provider != global.ProviderAzure {
+			problems = append(problems, problemAt(n, fmt.Sprintf("%s.%s 仅适用于 azure 服务商", prefix, key)))
+		}
+	}
+
+	apiKey, credentialName := child(item, "api_key"), child(item, "credential")
+	hasKey := apiKey != nil && strings.TrimSpace(apiKey.Value) != ""
+	hasCredential := credentialName != nil && strings.TrimSpace(credentialName.Value) != ""
+	if !hasKey && !hasCredential && provider != global.ProviderOllama {
+		problems = append(problems, problemAt(item, prefix+" 缺少 api_key 或 credential"))
+	}
+
+	for _, key := range []string{"input", "output"} {
+		n := child(child(item, "price"), key)
+		if n == nil || n.Kind != yaml.ScalarNode {
+			continue
+		}
+		if v, err := strconv.ParseFloat(n.Value, 64); err == nil && v < 0 {
+			problems = append(problems, problemAt(n, fmt.Sprintf("%s.price.%s 不能为负数", prefix, key)))
+		}
+	}
+
+	if transport := child(item, "transport"); transport != nil && transport.Kind == yaml.MappingNode {
+		problems = append(problems, validateTransport(transport, prefix+".transport")...)
+	}
+	return append(problems, checkModelEndpoint(item, prefix)...)
+}
+
+// validateTransport 校验模型的超时、代理地址与客户端证书设置
+func validateTransport(transport *yaml.Node, prefix string) []Problem {
+	var problems []Problem
+	for _, key := range []string{"connect_timeout", "first_byte_timeout", "idle_timeout"} {
+		n := child(transport, key)
+		if n == nil || n.Kind != yaml.ScalarNode {
+			continue
+		}
+		if d, err := time.ParseDuration(n.Value); err == nil && d < 0 {
+			problems = append(problems, problemAt(n, fmt.Sprintf("%s.%s 不能为负数", prefix, key)))
+		}
+	}
+
+	if n := child(transport, "proxy"); n != nil && n.Value != "" {
+		if expanded, err := ExpandEnv(n.Value); err == nil {
+			u, err := url.Parse(expanded)
+			if err != nil || !contains([]string{"http", "https", "socks5", "socks5h"}, u.Scheme) || u.Host == "" {
+				problems = append(problems, problemAt(n, fmt.Sprintf("%s.proxy 的值 %q 无效，应以 http://、https:// 或 socks5:// 开头", prefix, expanded)))
+			}
+		}
+	}
+
+	cert, key := child(transport, "cert_file"), child(transport, "key_file")
+	hasCert, hasKey := cert != nil && cert.Value != "", key != nil && key.Value != ""
+	if hasCert != hasKey {
+		problems = append(problems, problemAt(transport, prefix+" 的 cert_file 与 key_file 需要同时设置"))
+	}
+	return problems
+}
+
+// checkURL 检查 URL 格式，引用了未设置的环境变量时跳过检查，由加载时的警告提示
+func checkURL(raw string) error {
+	expanded, err := ExpandEnv(raw)
+	if err != nil {
+		return nil
+	}
+	u, err := url.Parse(expanded)
+	if err != nil {
+		return fmt.Errorf("格式无效: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q 必须以 http:// 或 https:// 开头", expanded)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q 缺少主机名", expanded)
+	}
+	// url.Parse 会转义主机名中的非法字符而不报错，未替换的 <资源名称> 等占位符需要单独检查
+	if strings.ContainsAny(u.Host, "<>{}\"\\^`| ") {
+		return fmt.Errorf("%q 的主机名 %s 无效，请替换其中的占位符", expanded, u.Host)
+	}
+	return nil
+}
+
+// child 返回映射中指定键的值节点，不存在时返回 nil
+func child(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	if idx := mappingIndex(n, key); idx >= 0 {
+		return n.Content[idx+1]
+	}
+	return nil
+}
+
+// problemAt 在节点所在位置创建问题，来源文件取自合并时记录的行尾注释
+func problemAt(n *yaml.Node, msg string) Problem {
+	return Problem{File: nodeSource(n), Line: n.Line, Column: n.Column, Message: msg}
+}
+
+// nodeSource 返回节点的来源文件，映射节点取其第一个标量值的来源
+func nodeSource(n *yaml.Node) string {
+	if n.Kind == yaml.ScalarNode {
+		return n.LineComment
+	}
+	for _, c := range n.Content {
+		if c.Kind == yaml.ScalarNode && c.LineComment != "" {
+			return c.LineComment
+		}
+		if c.Kind != yaml.ScalarNode {
+			if source := nodeSource(c); source != "" {
+				return source
+			}
+		}
+	}
+	return ""
+}
+
+// isKnownProvider 判断服务商是否受支持
+func isKnownProvider(p global.Provider) bool {
+	for _, known := range global.KnownProviders {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// knownProviderNames 返回所有支持的服务商名称
+func knownProviderNames() string {
+	names := make([]string, len(global.KnownProviders))
+	for i, p := range global.KnownProviders {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// contains 判断字符串是否在列表中
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `models:
+  - model: gpt-4o
+    api_key: sk-1234567890
+    url: https://api.openai.com/v1/chat/completions
+  - model: gpt-4o
+    api_key: sk-1234567890
+    url: ftp://example.com
+  - model: local
+    provider: llama
+    url: http://localhost:11434/v1/chat/completions
+    temprature: 0.3
+    price:
+      input: -1
+      outptu: 2
+logger:
+  level: verbose
+  max_size: 20000
+tools:
+  run_code: sometimes
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := Validate([]string{path})
+	want := []string{
+		":11:5: 未知配置项 models[2].temprature",
+		":5:12: 模型名称 gpt-4o 重复",
+		":7:10: models[1].url",
+		":9:15: models[2].provider 的值 \"llama\" 无效",
+		":8:5: models[2] 缺少 api_key 或 credential",
+		":14:7: 未知配置项 models[2].price.outptu",
+		":13:14: models[2].price.input 不能为负数",
+		":16:10: logger.level 的值 \"verbose\" 无效",
+		":17:13: logger.max_size 的值 20000 超出范围",
+		":19:13: tools.run_code 的值 \"sometimes\" 无效",
+	}
+	if len(problems) != len(want) {
+		t.Errorf("Validate() returned %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for _, w := range want {
+		found := false
+		for _, p := range problems {
+			if strings.Contains(p.String(), path+w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing problem %q in %v", w, problems)
+		}
+	}
+}
+
+func TestValidateSyntaxAndTypes(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.yaml")
+	if err := os.WriteFile(broken, []byte("models:\n  - model: a\n    url: x\n  bad\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	problems := Validate([]string{broken})
+	if len(problems) != 1 || problems[0].Line == 0 {
+		t.Errorf("Validate() syntax error = %v, want one problem with line number", problems)
+	}
+
+	typed := filepath.Join(dir, "typed.yaml")
+	if err := os.WriteFile(typed, []byte("logger:\n  max_age: forever\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	problems = Validate([]string{typed})
+	if len(problems) != 1 || problems[0].Line != 2 {
+		t.Errorf("Validate() type error = %v, want one problem on line 2", problems)
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `models:
+  - model: llama3
+    provider: ollama
+    url: ${OLLAMA_HOST:-http://localhost:11434}/v1/chat/completions
+  - model: gpt-4o
+    credential: openai
+    url: https://api.openai.com/v1/chat/completions
+    headers:
+      api-key: ${AZURE_KEY:-none}
+    transport:
+      connect_timeout: 5s
+      first_byte_timeout: 1m30s
+      proxy: socks5://127.0.0.1:1080
+      cert_file: client.pem
+      key_file: client.key
+  - model: gpt-4o-azure
+    provider: azure
+    deployment: chat
+    api_version: 2025-01-01-preview
+    api_key: key
+    url: https://demo.openai.azure.com
+logger:
+  level: debug
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if problems := Validate([]string{path}); len(problems) != 0 {
+		t.Errorf("Validate() = %v, want no problems", problems)
+	}
+}
+
+func TestValidateTransport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `models:
+  - model: gpt-4o
+    api_key: sk-1234567890
+    url: https://api.openai.com/v1/chat/completions
+    transport:
+      connect_timeout: 10
+      idle_timeout: -5s
+      proxy: ftp://proxy
+      cert_file: client.pem
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := Validate([]string{path})
+	want := []string{
+		":6: cannot unmarshal !!int `10` into time.Duration",
+		":7:21: models[0].transport.idle_timeout 不能为负数",
+		":8:14: models[0].transport.proxy 的值 \"ftp://proxy\" 无效",
+		":6:7: models[0].transport 的 cert_file 与 key_file 需要同时设置",
+	}
+	for _, w := range want {
+		found := false
+		for _, p := range problems {
+			if strings.Contains(p.String(), path+w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing problem %q in %v", w, problems)
+		}
+	}
+}
+
+func TestValidateAzure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `models:
+  - model: gpt-4o
+    provider: azure
+    api_version: latest
+    api_key: key
+    url: https://demo.openai.azure.com
+  - model: gpt-4o-mini
+    deployment: mini
+    api_key: key
+    url: https://api.openai.com/v1/chat/completions
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := Validate([]string{path})
+	want := []string{
+		":4:18: models[0].api_version 的值 \"latest\" 无效",
+		":8:17: models[1].deployment 仅适用于 azure 服务商",
+	}
+	if len(problems) != len(want) {
+		t.Errorf("Validate() returned %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for _, w := range want {
+		found := false
+		for _, p := range problems {
+			if strings.Contains(p.String(), path+w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing problem %q in %v", w, problems)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package credential
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// PassphraseEnv 提供凭据口令的环境变量，适用于脚本等非交互场景
+const PassphraseEnv = "SPARROW_CLI_PASSPHRASE"
+
+// FileName 凭据文件名
+const FileName = "credentials.enc"
+
+// DefaultPath 返回家目录下的凭据文件路径
+func DefaultPath(home string) string {
+	return filepath.Join(home, FileName)
+}
+
+// Unlock 读取口令并打开凭据文件。凭据文件尚不存在时要求输入两次口令以确认。
+// param path 为凭据文件路径。
+//
+// return 打开的凭据存储和可能的错误。
+func Unlock(path string) (*Store, error) {
+	passphrase, err := ReadPassphrase(!Exists(path))
+	if err != nil {
+		return nil, err
+	}
+	return Open(path, passphrase)
+}
+
+// ReadPassphrase 读取凭据口令，优先使用环境变量 SPARROW_CLI_PASSPHRASE，否则在终端中隐藏输入。
+// param confirm 为 true 时要求再次输入以确认，用于首次创建凭据文件。
+//
+// return 口令和可能的错误。
+func ReadPassphrase(confirm bool) ([]byte, error) {
+	if v := os.Getenv(PassphraseEnv); v != "" {
+		return []byte(v), nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("标准输入不是终端，请通过环境变量 %s 提供凭据口令", PassphraseEnv)
+	}
+
+	passphrase, err := readHidden("请输入凭据口令: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("口令不能为空")
+	}
+	if confirm {
+		again, err := readHidden("请再次输入凭据口令: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, fmt.Errorf("两次输入的口令不一致")
+		}
+	}
+	return passphrase, nil
+}
+
+// ReadSecret 读取密钥内容，终端中隐藏输入，否则从标准输入读取一行。
+// param prompt 为提示文本。
+//
+// return 去除首尾空白的密钥和可能的错误。
+func ReadSecret(prompt string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		secret, err := readHidden(prompt)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("读取密钥失败: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readHidden 在终端中不回显地读取一行
+func readHidden(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("读取输入失败: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,260 @@
+package credential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sparrow-cli/file"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	formatVersion = 1 // 凭据文件格式版本
+	keyLength     = 32
+	saltLength    = 16
+
+	// scrypt 参数，参考 golang.org/x/crypto/scrypt 推荐值
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// 从文件读取的 scrypt 参数上限，防止损坏或被篡改的文件使解锁耗尽内存或长时间卡住。
+	// 内存占用约为 128*N*r 字节，上限约 1 GiB
+	maxScryptN      = 1 << 20
+	maxScryptR      = 32
+	maxScryptP      = 16
+	maxScryptMemory = 1 << 30
+)
+
+// ErrWrongPassphrase 口令错误或凭据文件被篡改
+var ErrWrongPassphrase = errors.New("口令错误或凭据文件已损坏")
+
+// Credential 一条 API 密钥凭据
+type Credential struct {
+	Secret    string    `json:"secret"`     // 密钥内容
+	CreatedAt time.Time `json:"created_at"` // 创建时间
+	UpdatedAt time.Time `json:"updated_at"` // 最近一次轮换时间
+}
+
+// envelope 凭据文件的磁盘格式，密钥内容只以密文形式保存
+type envelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Store 加密的凭据存储，使用 scrypt 从口令派生密钥并以 AES-256-GCM 加密
+type Store struct {
+	path        string
+	passphrase  []byte
+	credentials map[string]*Credential
+}
+
+// Open 打开凭据文件，文件不存在时返回空的凭据存储，保存时才会创建文件。
+// param path 为凭据文件路径。
+// param passphrase 为解密口令。
+//
+// return 打开的凭据存储和可能的错误。口令错误时返回 ErrWrongPassphrase。
+func Open(path string, passphrase []byte) (*Store, error) {
+	s := &Store{
+		path:        path,
+		passphrase:  passphrase,
+		credentials: make(map[string]*Credential),
+	}
+	if !file.IsExist(path) {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取凭据文件失败 %s: %w", path, err)
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("解析凭据文件失败 %s: %w", path, err)
+	}
+	if env.Version != formatVersion || env.KDF != "scrypt" {
+		return nil, fmt.Errorf("不支持的凭据文件格式: version=%d kdf=%s", env.Version, env.KDF)
+	}
+
+	if err := checkParams(env); err != nil {
+		return nil, fmt.Errorf("凭据文件 %s 无效: %w", path, err)
+	}
+
+	gcm, err := newGCM(passphrase, env.Salt, env.N, env.R, env.P)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("凭据文件 %s 无效: nonce 长度为 %d", path, len(env.Nonce))
+	}
+	plaintext, err := gcm.Open(nil, env.Nonce, env.Ciphertext, additionalData(env))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	if err := json.Unmarshal(plaintext, &s.credentials); err != nil {
+		return nil, fmt.Errorf("解析凭据内容失败: %w", err)
+	}
+	return s, nil
+}
+
+// Reload 使用相同的口令重新读取凭据文件，用于获取其他进程写入的变更
+func (s *Store) Reload() (*Store, error) {
+	return Open(s.path, s.passphrase)
+}
+
+// Exists 判断凭据文件是否已经存在
+func Exists(path string) bool {
+	return file.IsExist(path)
+}
+
+// Get 按名称获取密钥
+func (s *Store) Get(name string) (string, bool) {
+	c, ok := s.credentials[name]
+	if !ok {
+		return "", false
+	}
+	return c.Secret, true
+}
+
+// Info 按名称获取凭据信息
+func (s *Store) Info(name string) (Credential, bool) {
+	c, ok := s.credentials[name]
+	if !ok {
+		return Credential{}, false
+	}
+	return *c, true
+}
+
+// Names 返回按名称排序的所有凭据名称
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.credentials))
+	for name := range s.credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Add 添加新凭据，名称已存在时返回错误
+func (s *Store) Add(name, secret string) error {
+	if _, ok := s.credentials[name]; ok {
+		return fmt.Errorf("凭据已存在: %s，如需更换密钥请使用 rotate", name)
+	}
+	now := time.Now()
+	s.credentials[name] = &Credential{Secret: secret, CreatedAt: now, UpdatedAt: now}
+	return nil
+}
+
+// Rotate 更换已有凭据的密钥
+func (s *Store) Rotate(name, secret string) error {
+	c, ok := s.credentials[name]
+	if !ok {
+		return fmt.Errorf("凭据不存在: %s", name)
+	}
+	c.Secret = secret
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// Remove 删除凭据
+func (s *Store) Remove(name string) error {
+	if _, ok := s.credentials[name]; !ok {
+		return fmt.Errorf("凭据不存在: %s", name)
+	}
+	delete(s.credentials, name)
+	return nil
+}
+
+// Save 使用新的盐与随机数重新加密并写入凭据文件，先写入临时文件再替换以免损坏原文件
+func (s *Store) Save() error {
+	plaintext, err := json.Marshal(s.credentials)
+	if err != nil {
+		return fmt.Errorf("序列化凭据失败: %w", err)
+	}
+
+	env := envelope{
+		Version: formatVersion,
+		KDF:     "scrypt",
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, saltLength),
+	}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return fmt.Errorf("生成随机盐失败: %w", err)
+	}
+	gcm, err := newGCM(s.passphrase, env.Salt, env.N, env.R, env.P)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return fmt.Errorf("生成随机数失败: %w", err)
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, plaintext, additionalData(env))
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化凭据文件失败: %w", err)
+	}
+	if err := file.EnsureDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("写入凭据文件失败 %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("替换凭据文件失败 %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// checkParams 检查文件中的 scrypt 参数是否在合理范围内
+func checkParams(env envelope) error {
+	switch {
+	case env.N < 2 || env.N > maxScryptN || env.N&(env.N-1) != 0:
+		return fmt.Errorf("scrypt 参数 n=%d 无效，应为不超过 %d 的 2 的幂", env.N, maxScryptN)
+	case env.R < 1 || env.R > maxScryptR:
+		return fmt.Errorf("scrypt 参数 r=%d 超出范围 1-%d", env.R, maxScryptR)
+	case env.P < 1 || env.P > maxScryptP:
+		return fmt.Errorf("scrypt 参数 p=%d 超出范围 1-%d", env.P, maxScryptP)
+	case 128*env.N*env.R > maxScryptMemory:
+		return fmt.Errorf("scrypt 参数 n=%d r=%d 需要的内存超过 %d MiB", env.N, env.R, maxScryptMemory>>20)
+	case len(env.Salt) == 0:
+		return fmt.Errorf("缺少 salt")
+	}
+	return nil
+}
+
+// newGCM 从口令派生密钥并创建 AES-GCM 加密器
+func newGCM(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData 将格式与派生参数作为附加认证数据，防止参数被篡改
+func additionalData(env envelope) []byte {
+	return []byte(fmt.Sprintf("sparrow-cli/v%d/%s/%d/%d/%d", env.Version, env.KDF, env.N, env.R, env.P))
+}
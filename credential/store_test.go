@@ -0,0 +1,88 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	s, err := Open(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := s.Add("openai", "sk-secret-value"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("openai", "again"); err == nil {
+		t.Errorf("Add() should reject duplicate names")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("sk-secret-value")) {
+		t.Fatalf("credential file contains the plaintext secret")
+	}
+
+	if _, err := Open(path, []byte("wrong")); err != ErrWrongPassphrase {
+		t.Errorf("Open() with wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+
+	reopened, err := Open(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := reopened.Rotate("openai", "sk-rotated"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if secret, ok := reopened.Get("openai"); !ok || secret != "sk-rotated" {
+		t.Errorf("Get() = %q, %v", secret, ok)
+	}
+	if err := reopened.Remove("openai"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if len(reopened.Names()) != 0 {
+		t.Errorf("Names() = %q, want empty", reopened.Names())
+	}
+}
+
+func TestOpenRejectsTamperedParams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	s, _ := Open(path, []byte("pass"))
+	if err := s.Add("openai", "sk-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+
+	for name, tamper := range map[string]func(*envelope){
+		"huge n":       func(e *envelope) { e.N = 1 << 40 },
+		"huge r":       func(e *envelope) { e.R = 1 << 20 },
+		"huge p":       func(e *envelope) { e.P = 1 << 20 },
+		"memory":       func(e *envelope) { e.N, e.R = 1<<20, 16 },
+		"n not power":  func(e *envelope) { e.N = 1000 },
+		"short nonce":  func(e *envelope) { e.Nonce = e.Nonce[:4] },
+		"missing salt": func(e *envelope) { e.Salt = nil },
+	} {
+		var env envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			t.Fatal(err)
+		}
+		tamper(&env)
+		tampered, _ := json.Marshal(env)
+		if err := os.WriteFile(path, tampered, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path, []byte("pass")); err == nil || err == ErrWrongPassphrase {
+			t.Errorf("%s: Open() error = %v, want invalid file error", name, err)
+		}
+	}
+}
@@ -78,6 +78,28 @@ func CreateFile(path string) (*os.File, error) {
 	return file, nil
 }
 
+// WriteNewFile 创建新文件并写入内容，文件已存在时返回错误而不是覆盖。
+// 文件通过 CreateFile 创建，同样要求文件名包含扩展名，并会自动创建父目录。
+//
+// param path 为要创建的文件路径。
+// param data 为写入的内容。
+//
+// return 可能的错误。文件已存在或写入失败时返回错误。
+func WriteNewFile(path string, data []byte) error {
+	if IsExist(path) {
+		return fmt.Errorf("文件已存在: %s", path)
+	}
+	f, err := CreateFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("写入文件失败 %s: %w", path, err)
+	}
+	return f.Close()
+}
+
 // CompressFileToTarGz 将指定文件压缩为 tar.gz 格式。
 // 如果目标路径为空，将使用默认命名规则：同目录下的 <源文件名>.tar.gz
 //
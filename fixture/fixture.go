@@ -0,0 +1,131 @@
+// Package fixture 录制与回放模型接口的 HTTP 请求，用于离线测试与复现问题。
+// 录制文件为 YAML 格式，按顺序保存请求与响应，API 密钥、Cookie 与配置中的额外请求头等敏感信息会被遮盖。
+package fixture
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sparrow-cli/file"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted 敏感信息被遮盖后的值
+const Redacted = "REDACTED"
+
+// sensitiveHeaders 录制时总是遮盖的请求头与响应头，模型配置中的额外请求头由 Recorder.RedactHeaders 指定
+var sensitiveHeaders = []string{"Authorization", "Api-Key", "X-Api-Key", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveParams 录制时遮盖的 URL 参数
+var sensitiveParams = []string{"key", "api_key", "api-key", "access_token"}
+
+// Interaction 一次请求与响应
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+// Request 录制的请求
+type Request struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+// Response 录制的响应
+type Response struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body"`
+}
+
+// Cassette 录制文件的内容
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Load 读取录制文件
+// param path 为录制文件路径。
+//
+// return 录制内容和可能的错误。
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取录制文件失败: %w", err)
+	}
+	var c Cassette
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("解析录制文件失败 %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save 将录制内容写入文件，先写入临时文件再替换以免损坏原文件
+// param path 为录制文件路径。
+//
+// return 可能的错误。
+func (c *Cassette) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("编码录制内容失败: %w", err)
+	}
+	if err := file.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	return nil
+}
+
+// redactURL 遮盖 URL 中的密钥参数
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for _, name := range sensitiveParams {
+		if query.Has(name) {
+			query.Set(name, Redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// redactHeaders 复制请求头并遮盖敏感的值，同名多值以逗号连接
+// param h 为请求头或响应头。
+// param extra 为 sensitiveHeaders 之外需要遮盖的名称，不区分大小写。
+//
+// return 遮盖后的请求头。
+func redactHeaders(h http.Header, extra []string) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	names := slices.Concat(sensitiveHeaders, extra)
+	headers := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		for _, sensitive := range names {
+			if http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(sensitive) {
+				value = Redacted
+				break
+			}
+		}
+		headers[name] = value
+	}
+	return headers
+}
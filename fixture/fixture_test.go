@@ -0,0 +1,84 @@
+package fixture
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+		w.Write([]byte("data: " + string(body) + "\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	recorder := NewRecorder(path)
+	recorder.RedactHeaders(func() []string { return []string{"x-portkey-api-key"} })
+	recording := &http.Client{Transport: recorder.Wrap(http.DefaultTransport)}
+
+	send := func(c *http.Client, body string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/chat/completions?key=secret-key", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer sk-secret")
+		req.Header.Set("api-key", "azure-secret")
+		req.Header.Set("X-Portkey-Api-Key", "gateway-secret")
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	first := send(recording, `{"n":1}`)
+	second := send(recording, `{"n":2}`)
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("recorder error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk-secret", "azure-secret", "secret-key", "gateway-secret", "cookie-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains secret %q:\n%s", secret, data)
+		}
+	}
+
+	// 请求体相同的录制优先，其次按顺序回放
+	replayer, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaying := &http.Client{Transport: replayer}
+	if got := send(replaying, `{"n":2}`); got != second {
+		t.Errorf("replay body = %q, want %q", got, second)
+	}
+	if got := send(replaying, `{"n":3}`); got != first {
+		t.Errorf("replay fallback body = %q, want %q", got, first)
+	}
+	if replayer.Remaining() != 0 {
+		t.Errorf("Remaining() = %d, want 0", replayer.Remaining())
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/chat/completions", strings.NewReader("{}"))
+	if _, err := replaying.Do(req); err == nil {
+		t.Errorf("replay without matching interaction should fail")
+	}
+}
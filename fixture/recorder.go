@@ -0,0 +1,136 @@
+package fixture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Recorder 录制经过的请求与响应，每完成一次请求就写入录制文件
+type Recorder struct {
+	path string // 录制文件路径
+
+	mu       sync.Mutex
+	cassette Cassette
+	err      error           // 最近一次写入录制文件失败的原因
+	headers  func() []string // 返回额外需要遮盖的请求头名称，可为 nil
+}
+
+// NewRecorder 创建录制器，已有的录制文件会被覆盖
+// param path 为录制文件路径。
+//
+// return 录制器。
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// RedactHeaders 设置额外需要遮盖的请求头，例如模型配置中的 headers，其值可能是密钥。
+// 每次录制时调用 names 获取名称，配置重新加载后新增的请求头同样会被遮盖
+func (r *Recorder) RedactHeaders(names func() []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.headers = names
+}
+
+// extraHeaders 返回额外需要遮盖的请求头名称
+func (r *Recorder) extraHeaders() []string {
+	r.mu.Lock()
+	names := r.headers
+	r.mu.Unlock()
+	if names == nil {
+		return nil
+	}
+	return names()
+}
+
+// Wrap 返回经过 base 发送请求并录制的传输层，多个传输层可以录制到同一个文件
+func (r *Recorder) Wrap(base http.RoundTripper) http.RoundTripper {
+	return &recordingTransport{recorder: r, base: base}
+}
+
+// recordingTransport 发送请求并录制的传输层
+type recordingTransport struct {
+	recorder *Recorder
+	base     http.RoundTripper
+}
+
+// RoundTrip 发送请求并录制。响应体在读取的同时被记录，流式响应仍可逐块输出，读取完毕或关闭时写入录制文件
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := t.recorder.extraHeaders()
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     redactURL(req.URL),
+			Headers: redactHeaders(req.Header, extra),
+			Body:    string(body),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: redactHeaders(resp.Header, extra),
+		},
+	}
+	resp.Body = &recordingBody{ReadCloser: resp.Body, recorder: t.recorder, interaction: interaction}
+	return resp, nil
+}
+
+// Err 返回最近一次写入录制文件失败的原因
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// add 追加一次请求并写入录制文件
+func (r *Recorder) add(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.err = r.cassette.Save(r.path)
+}
+
+// recordingBody 在读取响应体的同时记录内容
+type recordingBody struct {
+	io.ReadCloser
+	recorder    *Recorder
+	interaction Interaction
+	buf         bytes.Buffer
+	once        sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+// finish 记录已读取的响应体，只执行一次
+func (b *recordingBody) finish() {
+	b.once.Do(func() {
+		b.interaction.Response.Body = b.buf.String()
+		b.recorder.add(b.interaction)
+	})
+}
@@ -0,0 +1,124 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Replayer 按录制文件回放响应，不会发送任何网络请求。
+// 优先匹配方法、地址与请求体都相同的录制，其次按顺序使用方法、地址与请求体中的模型名称相同的录制，
+// 每条录制只使用一次。
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer 创建回放器
+// param c 为录制内容。
+//
+// return 回放器。
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}
+}
+
+// LoadReplayer 读取录制文件并创建回放器
+// param path 为录制文件路径。
+//
+// return 回放器和可能的错误。
+func LoadReplayer(path string) (*Replayer, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(c), nil
+}
+
+// RoundTrip 返回与请求匹配的录制响应，没有匹配的录制时返回错误
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	url := redactURL(req.URL)
+
+	r.mu.Lock()
+	index := r.match(req.Method, url, string(body))
+	if index >= 0 {
+		r.used[index] = true
+	}
+	r.mu.Unlock()
+	if index < 0 {
+		return nil, fmt.Errorf("录制文件中没有与请求匹配的响应: %s %s", req.Method, url)
+	}
+
+	recorded := r.interactions[index].Response
+	header := make(http.Header, len(recorded.Headers))
+	for name, value := range recorded.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining 返回尚未回放的录制数量
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// match 查找未使用的匹配录制，返回其序号，没有时返回 -1
+func (r *Replayer) match(method, url, body string) int {
+	fallback := -1
+	model := bodyModel(body)
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Request.Method != method || interaction.Request.URL != url {
+			continue
+		}
+		if interaction.Request.Body == body {
+			return i
+		}
+		if fallback < 0 && bodyModel(interaction.Request.Body) == model {
+			fallback = i
+		}
+	}
+	return fallback
+}
+
+// bodyModel 返回 JSON 请求体中的模型名称，不是 JSON 或没有模型名称时返回空字符串
+func bodyModel(body string) string {
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		return ""
+	}
+	return req.Model
+}
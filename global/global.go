@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 // =============================================================================
@@ -13,21 +14,50 @@ import (
 
 // Model 全局模型配置
 type Model struct {
-	Name   string // 模型名称
-	ApiKey string // API密钥
-	URL    string // API地址
+	Name        string   // 模型名称
+	ApiKey      string   // API密钥
+	URL         string   // API地址
+	Provider    Provider // 模型服务商
+	InputPrice  float64  // 每百万输入 token 的价格，0 表示未配置
+	OutputPrice float64  // 每百万输出 token 的价格，0 表示未配置
+	Currency    string   // 价格的货币符号
+	Deployment  string   // Azure 部署名称，为空时与模型名称相同
+	APIVersion  string   // Azure 接口版本，为空时使用默认版本
+
+	Headers   map[string]string // 额外的请求头
+	Transport Transport         // 网络传输设置
+}
+
+// Transport 模型请求的网络传输设置，零值表示使用默认值
+type Transport struct {
+	ConnectTimeout     time.Duration // 建立连接（含 TLS 握手）的超时时间
+	FirstByteTimeout   time.Duration // 发出请求后等待响应头的超时时间
+	IdleTimeout        time.Duration // 流式输出中两次收到数据的最长间隔
+	Proxy              string        // 代理地址，支持 http、https、socks5，为空时使用 HTTPS_PROXY 等环境变量
+	CAFile             string        // 额外信任的 CA 证书文件（PEM）
+	CertFile           string        // 客户端证书文件（PEM）
+	KeyFile            string        // 客户端证书私钥文件（PEM）
+	InsecureSkipVerify bool          // 跳过服务端证书校验，仅用于内部网关
 }
 
-// CurrentModel 当前使用的模型
+// CurrentModel 当前使用的模型，仅作为交互对话的默认模型；发送请求时应显式传入模型
 var CurrentModel *Model
 
 // SetCurrentModel 设置当前模型
-func SetCurrentModel(name, apiKey, url string) {
-	CurrentModel = &Model{
-		Name:   name,
-		ApiKey: apiKey,
-		URL:    url,
+func SetCurrentModel(model *Model) {
+	CurrentModel = model
+}
+
+// Cost 按模型价格计算一次请求的费用
+// param promptTokens 为输入 token 数量。
+// param completionTokens 为输出 token 数量。
+//
+// return 费用和是否配置了价格。
+func (m *Model) Cost(promptTokens, completionTokens int) (float64, bool) {
+	if m.InputPrice == 0 && m.OutputPrice == 0 {
+		return 0, false
 	}
+	return (float64(promptTokens)*m.InputPrice + float64(completionTokens)*m.OutputPrice) / 1e6, true
 }
 
 // =============================================================================
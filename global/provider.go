@@ -0,0 +1,35 @@
+package global
+
+// =============================================================================
+// 模型服务商
+// =============================================================================
+
+// Provider 模型服务商，不同服务商在请求格式上存在差异
+type Provider string
+
+const (
+	ProviderOpenAI   Provider = "openai"   // OpenAI 及兼容 OpenAI 接口的服务，默认值
+	ProviderDeepSeek Provider = "deepseek" // DeepSeek，仅支持文本输入
+	ProviderQwen     Provider = "qwen"     // 通义千问 DashScope 兼容模式
+	ProviderZhipu    Provider = "zhipu"    // 智谱 GLM，图片需以不带前缀的 Base64 传入
+	ProviderOllama   Provider = "ollama"   // Ollama 本地模型的 OpenAI 兼容接口
+	ProviderAzure    Provider = "azure"    // Azure OpenAI，按部署名称拼接接口地址，使用 api-key 请求头认证
+)
+
+// KnownProviders 所有支持的服务商
+var KnownProviders = []Provider{
+	ProviderOpenAI,
+	ProviderDeepSeek,
+	ProviderQwen,
+	ProviderZhipu,
+	ProviderOllama,
+	ProviderAzure,
+}
+
+// ParseProvider 将配置中的服务商名称转换为 Provider，空字符串视为 openai
+func ParseProvider(name string) Provider {
+	if name == "" {
+		return ProviderOpenAI
+	}
+	return Provider(name)
+}
@@ -0,0 +1,178 @@
+package global
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// =============================================================================
+// 提示词模板
+// =============================================================================
+
+// maxTemplateFileBytes 模板中 file 函数读取文件的最大字节数
+const maxTemplateFileBytes = 256 * 1024
+
+// templateCommandTimeout 模板中 git、sh 函数执行命令的超时时间
+const templateCommandTimeout = 10 * time.Second
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	"env":  os.Getenv,
+	"file": templateFile,
+	"git":  templateGit,
+	"sh":   templateShell,
+}
+
+// RenderPrompt 渲染提示词模板。
+// 模板使用 Go text/template 语法，内置变量包括 .Date、.Time、.Cwd、.Model，
+// 可用函数包括 env "X"、file "README.md"、git "diff --staged"、sh "命令"。
+// git 的参数可以写成一个字符串，按 shell 的规则拆分并支持引号，例如 git "log --format='%h %s'"，
+// 也可以逐个传入，例如 git "log" "--format=%h %s"。
+// 不包含 {{ 的文本原样返回。
+// param text 为模板文本。
+// param vars 为用户声明的变量，可通过 {{.变量名}} 引用，同名时覆盖内置变量。
+//
+// return 渲染后的文本和可能的错误。
+func RenderPrompt(text string, vars map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("prompt").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("解析提示词模板失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData(vars)); err != nil {
+		return "", fmt.Errorf("渲染提示词模板失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// templateData 构造模板变量
+func templateData(vars map[string]string) map[string]any {
+	now := time.Now()
+	cwd, _ := os.Getwd()
+	model := ""
+	if CurrentModel != nil {
+		model = CurrentModel.Name
+	}
+
+	data := map[string]any{
+		"Date":  now.Format("2006-01-02"),
+		"Time":  now.Format("15:04"),
+		"Cwd":   cwd,
+		"Model": model,
+	}
+	for k, v := range vars {
+		data[k] = v
+	}
+	return data
+}
+
+// templateFile 读取文件内容
+func templateFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败 %s: %w", path, err)
+	}
+	if info.Size() > maxTemplateFileBytes {
+		return "", fmt.Errorf("文件 %s 大小超过上限 %d 字节", path, maxTemplateFileBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败 %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// templateGit 在当前目录执行 git 子命令并返回输出，只传入一个参数时按 shell 的规则拆分
+func templateGit(args ...string) (string, error) {
+	if len(args) == 1 {
+		words, err := splitWords(args[0])
+		if err != nil {
+			return "", fmt.Errorf("解析 git 参数失败: %w", err)
+		}
+		args = words
+	}
+	return runTemplateCommand("git", args...)
+}
+
+// splitWords 按 shell 的规则拆分参数：空白分隔参数，单引号内原样保留，双引号内支持 \" 与 \\ 转义，
+// 引号外的反斜杠转义下一个字符。不展开变量与通配符
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("引号或转义未结束: %s", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// templateShell 使用 sh 执行命令并返回输出
+func templateShell(command string) (string, error) {
+	return runTemplateCommand("sh", "-c", command)
+}
+
+// runTemplateCommand 执行命令，返回去掉末尾换行的标准输出
+func runTemplateCommand(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), templateCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("执行 %s %s 失败: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
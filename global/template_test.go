@@ -0,0 +1,32 @@
+package global
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "diff --staged", want: []string{"diff", "--staged"}},
+		{input: `log -3 --format="%h %s"`, want: []string{"log", "-3", "--format=%h %s"}},
+		{input: `log --format='%an <%ae>' -- "my file.go"`, want: []string{"log", "--format=%an <%ae>", "--", "my file.go"}},
+		{input: `show a\ b "say \"hi\""`, want: []string{"show", "a b", `say "hi"`}},
+		{input: `grep ''`, want: []string{"grep", ""}},
+		{input: "  ", want: nil},
+		{input: `log --format="%h`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := splitWords(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitWords(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
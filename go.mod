@@ -1,9 +1,12 @@
 module sparrow-cli
 
-go 1.25
+go 1.25.0
 
 require (
 	go.uber.org/zap v1.27.0
+	golang.org/x/crypto v0.54.0
+	golang.org/x/sys v0.47.0
+	golang.org/x/term v0.45.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,247 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sparrow-cli/file"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultMaxEntries    = 1000      // 默认最多保留的历史条数
+	DefaultMaxEntryBytes = 16 * 1024 // 默认单条历史的最大字节数
+
+	// compactFactor 文件中的行数超过保留条数的该倍数时，加载时压缩文件
+	compactFactor = 2
+)
+
+// Options 历史记录选项
+type Options struct {
+	MaxEntries    int  // 最多保留的历史条数，<= 0 时使用默认值
+	MaxEntryBytes int  // 单条历史的最大字节数，超过则不记录，<= 0 时使用默认值
+	IgnoreSpace   bool // 是否忽略以空格开头的输入（类似 shell 的 HISTCONTROL=ignorespace）
+}
+
+// History 持久化的输入历史
+type History struct {
+	mu      sync.RWMutex
+	path    string
+	opts    Options
+	entries []string
+}
+
+// Load 从指定文件加载历史记录，文件不存在时返回空历史。
+// 超过单条最大字节数的记录会被跳过；文件中的记录明显多于保留的条数时（重复、过长或超出条数），
+// 会将保留的记录重写回文件进行压缩。
+// param path 为历史文件路径，为空时仅在内存中保存历史。
+// param opts 为历史记录选项。
+//
+// return 加载完成的历史记录和可能的错误。
+func Load(path string, opts Options) (*History, error) {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultMaxEntries
+	}
+	if opts.MaxEntryBytes <= 0 {
+		opts.MaxEntryBytes = DefaultMaxEntryBytes
+	}
+
+	h := &History{path: path, opts: opts}
+	if path == "" || !file.IsExist(path) {
+		return h, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开历史文件失败 %s: %w", path, err)
+	}
+	defer f.Close()
+
+	lines, oversized := 0, 0
+	reader := bufio.NewReader(f)
+	for {
+		raw, err := reader.ReadString('\n')
+		if raw != "" {
+			lines++
+			// 单条最大字节数调小后，已有的过长记录直接跳过
+			switch line := decode(strings.TrimSuffix(raw, "\n")); {
+			case len(line) > opts.MaxEntryBytes:
+				oversized++
+			case line != "":
+				h.appendEntry(line)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取历史文件失败 %s: %w", path, err)
+		}
+	}
+
+	// 每次输入只追加一行，多个会话同时运行时不会互相覆盖，文件由加载时压缩。
+	// 压缩失败时保留原文件，不影响本次使用，下次加载时重试
+	if oversized > 0 || lines > compactFactor*opts.MaxEntries || lines > compactFactor*len(h.entries) {
+		_ = h.compact()
+	}
+	return h, nil
+}
+
+// Add 添加一条历史记录并持久化到文件。
+// 重复的输入会被移动到末尾，以空格开头的输入在开启 IgnoreSpace 时不会被记录。
+// param line 为用户输入的内容。
+//
+// return 可能的错误。写入历史文件失败时返回错误。
+func (h *History) Add(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	if h.opts.IgnoreSpace && strings.HasPrefix(line, " ") {
+		return nil
+	}
+	if len(line) > h.opts.MaxEntryBytes {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.appendEntry(line)
+	return h.append(line)
+}
+
+// Entries 返回全部历史记录的副本，按从旧到新排列
+func (h *History) Entries() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	entries := make([]string, len(h.entries))
+	copy(entries, h.entries)
+	return entries
+}
+
+// Len 返回历史记录条数
+func (h *History) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.entries)
+}
+
+// At 返回指定下标的历史记录
+func (h *History) At(i int) string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if i < 0 || i >= len(h.entries) {
+		return ""
+	}
+	return h.entries[i]
+}
+
+// Search 从下标 from（不含）开始向前查找包含 query 的历史记录。
+// param query 为查找的子串。
+// param from 为开始位置，传入 Len() 表示从最新一条开始查找。
+//
+// return 匹配项的下标，未找到时返回 -1。
+func (h *History) Search(query string, from int) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if from > len(h.entries) {
+		from = len(h.entries)
+	}
+	for i := from - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendEntry 追加一条记录，去除已有的相同记录并裁剪到最大条数
+func (h *History) appendEntry(line string) {
+	for i, entry := range h.entries {
+		if entry == line {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.opts.MaxEntries {
+		h.entries = h.entries[len(h.entries)-h.opts.MaxEntries:]
+	}
+}
+
+// append 以追加方式将一条记录写入历史文件，多个会话同时写入时各自的记录都会保留
+func (h *History) append(line string) error {
+	if h.path == "" {
+		return nil
+	}
+
+	if err := file.EnsureDir(filepath.Dir(h.path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开历史文件失败 %s: %w", h.path, err)
+	}
+	// 一次写入整行，避免与其他会话的写入交错
+	_, err = f.WriteString(encode(line) + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入历史文件失败 %s: %w", h.path, err)
+	}
+	return nil
+}
+
+// compact 将保留的记录写入临时文件后替换原文件，避免写入中断导致历史丢失
+func (h *History) compact() error {
+	var sb strings.Builder
+	for _, entry := range h.entries {
+		sb.WriteString(encode(entry))
+		sb.WriteByte('\n')
+	}
+
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(sb.String()), 0600); err != nil {
+		return fmt.Errorf("写入历史文件失败 %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return fmt.Errorf("替换历史文件失败 %s: %w", h.path, err)
+	}
+	return nil
+}
+
+// encode 转义换行与反斜杠，保证每条历史在文件中占一行
+func encode(line string) string {
+	line = strings.ReplaceAll(line, `\`, `\\`)
+	return strings.ReplaceAll(line, "\n", `\n`)
+}
+
+// decode 还原 encode 转义的内容
+func decode(line string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range line {
+		if escaped {
+			if r == 'n' {
+				sb.WriteByte('\n')
+			} else {
+				sb.WriteRune(r)
+			}
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
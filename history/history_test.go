@@ -0,0 +1,101 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h, err := Load(path, Options{MaxEntries: 3, IgnoreSpace: true})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, line := range []string{"a", "b", " secret", "a", "c", "多行\n输入", "d"} {
+		if err := h.Add(line); err != nil {
+			t.Fatalf("Add(%q) error = %v", line, err)
+		}
+	}
+
+	reloaded, err := Load(path, Options{MaxEntries: 3})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"c", "多行\n输入", "d"}
+	got := reloaded.Entries()
+	if len(got) != len(want) {
+		t.Fatalf("Entries() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Entries()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	h, _ := Load("", Options{})
+	for _, line := range []string{"git status", "go test", "git diff"} {
+		_ = h.Add(line)
+	}
+
+	if i := h.Search("git", h.Len()); i != 2 {
+		t.Errorf("Search(git) = %d, want 2", i)
+	}
+	if i := h.Search("git", 2); i != 0 {
+		t.Errorf("Search(git, 2) = %d, want 0", i)
+	}
+	if i := h.Search("rust", h.Len()); i != -1 {
+		t.Errorf("Search(rust) = %d, want -1", i)
+	}
+}
+
+func TestHistoryConcurrentSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	first, _ := Load(path, Options{})
+	second, _ := Load(path, Options{})
+	for _, step := range []struct {
+		h    *History
+		line string
+	}{{first, "one"}, {second, "two"}, {first, "three"}, {second, "one"}} {
+		if err := step.h.Add(step.line); err != nil {
+			t.Fatalf("Add(%q) error = %v", step.line, err)
+		}
+	}
+
+	reloaded, err := Load(path, Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"two", "three", "one"}
+	if got := reloaded.Entries(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Entries() = %q, want %q", got, want)
+	}
+}
+
+func TestHistoryOversizedEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h, _ := Load(path, Options{})
+	for _, line := range []string{"short", strings.Repeat("x", 100), "tail"} {
+		if err := h.Add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 调小单条最大字节数后，已有的过长记录被跳过而不是导致加载失败
+	reloaded, err := Load(path, Options{MaxEntryBytes: 10})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := reloaded.Entries(); strings.Join(got, ",") != "short,tail" {
+		t.Errorf("Entries() = %q, want [short tail]", got)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "short\ntail\n" {
+		t.Errorf("history file was not compacted: %q", data)
+	}
+}
@@ -15,6 +15,8 @@ import (
 var (
 	logger     *zap.SugaredLogger
 	loggerLock sync.Once
+	// level 日志级别，可在运行中随配置重新加载而调整
+	level = zap.NewAtomicLevel()
 )
 
 // InitLogger 初始化日志系统
@@ -33,7 +35,7 @@ func InitLogger(ctx context.Context) error {
 
 // initLogger 实际的初始化逻辑
 func initLogger() error {
-	loggerConf := config.Logger
+	loggerConf := config.Current().Logger
 
 	logDir := env.SparrowCliHome + "/logs"
 
@@ -57,10 +59,11 @@ func initLogger() error {
 	encoder := getEncoder()
 
 	// 创建核心记录器
+	level.SetLevel(getLogLevel(loggerConf.Level))
 	core := zapcore.NewCore(
 		encoder,
 		writeSyncer,
-		getLogLevel(loggerConf.Level),
+		level,
 	)
 
 	// 创建Logger
@@ -94,6 +97,11 @@ func getEncoder() zapcore.Encoder {
 	return zapcore.NewJSONEncoder(encoderConfig)
 }
 
+// SetLevel 调整日志级别，可在运行中并发调用
+func SetLevel(name string) {
+	level.SetLevel(getLogLevel(name))
+}
+
 // getLogLevel 获取日志级别
 func getLogLevel(level string) zapcore.Level {
 	switch strings.ToLower(level) {
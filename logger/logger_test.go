@@ -8,14 +8,20 @@ import (
 	"testing"
 )
 
-func init() {
-	homePath := os.Getenv("SparrowCliHome")
-	if homePath == "" {
-		homePath = os.Getenv("HOME") + "/.sparrow-cli"
+// TestMain 使用临时目录作为家目录，日志与配置不会写入真实的家目录
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "sparrow-cli-logger")
+	if err != nil {
+		panic(err)
 	}
-	env.SparrowCliHome = homePath
+	env.SparrowCliHome = home
 
-	config.LoadConfig()
+	if err := config.LoadConfig(); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
 }
 
 func TestInitLogger(t *testing.T) {
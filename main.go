@@ -1,21 +1,43 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"sparrow-cli/attach"
 	"sparrow-cli/client"
+	"sparrow-cli/codeblock"
 	"sparrow-cli/config"
 	"sparrow-cli/env"
+	"sparrow-cli/file"
+	"sparrow-cli/fixture"
 	"sparrow-cli/global"
+	"sparrow-cli/history"
 	"sparrow-cli/logger"
-	"strings"
+	"sparrow-cli/markdown"
+	"sparrow-cli/prompt"
+	"sparrow-cli/terminal"
 	"time"
 )
 
+var (
+	// rawOutput 是否原样输出模型回答，不渲染 Markdown
+	rawOutput = flag.Bool("raw", false, "原样输出模型回答，不渲染 Markdown")
+	// systemName 启动时使用的系统提示词名称
+	systemName = flag.String("system", "", "使用提示词库中指定名称的系统提示词")
+	// askSystem 是否在启动时交互式输入系统提示词
+	askSystem = flag.Bool("ask-system", false, "启动时交互式输入系统提示词")
+	// profileName 启动时使用的配置档案名称
+	profileName = flag.String("profile", "", "使用配置中指定名称的配置档案（模型、系统提示词、温度与工具权限）")
+	// recordPath 录制模型请求的文件路径
+	recordPath = flag.String("record", "", "将模型请求与响应录制到指定文件，API 密钥会被遮盖")
+	// replayPath 回放模型响应的文件路径
+	replayPath = flag.String("replay", "", "从 --record 录制的文件回放模型响应，不发送网络请求")
+)
+
 func initProjEnv() {
 	// 判断环境变量是否有 SparrowCliHome
 	//	- 若有，则从 SparrowCliHome 中加载配置文件并将该路径保存到全局变量 env.SparrowCliHome 中
@@ -35,100 +57,196 @@ func initComponents(ctx context.Context) {
 	}
 }
 
-func initSysRole(messages []client.Message) []client.Message {
-	// 初始化系统提示词
-	global.InitSystemPrompt()
-
-	// 使用全局管理的系统消息
-	messages = append(messages, client.Message{
-		Role:    client.SysRole,
-		Content: global.GetSystemPrompt(),
-	})
+// initFixture 按 --record 或 --replay 录制或回放模型请求，录制时返回录制器
+func initFixture() *fixture.Recorder {
+	switch {
+	case *recordPath != "" && *replayPath != "":
+		fmt.Fprintln(os.Stderr, "--record 与 --replay 不能同时使用")
+		os.Exit(2)
+	case *replayPath != "":
+		replayer, err := fixture.LoadReplayer(*replayPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		client.WrapTransport(func(http.RoundTripper) http.RoundTripper { return replayer })
+		fmt.Printf("回放模式: 模型响应来自 %s\n", *replayPath)
+	case *recordPath != "":
+		recorder := fixture.NewRecorder(*recordPath)
+		// 模型配置中的额外请求头可能携带密钥，录制时一并遮盖
+		recorder.RedactHeaders(func() []string {
+			var names []string
+			for _, m := range config.Current().Models {
+				for name := range m.Headers {
+					names = append(names, name)
+				}
+			}
+			return names
+		})
+		client.WrapTransport(recorder.Wrap)
+		fmt.Printf("录制模式: 模型请求将保存到 %s\n", *recordPath)
+		return recorder
+	}
+	return nil
+}
 
-	return messages
+// initPromptLibrary 加载提示词库
+func initPromptLibrary() *prompt.Library {
+	library, err := prompt.LoadLibrary(promptDir())
+	if err != nil {
+		// 提示词文件有误时不影响对话，仅使用内置提示词
+		logger.Warn("加载提示词库失败: %v", err)
+		fmt.Printf("警告: 加载提示词库失败: %v\n", err)
+		library, _ = prompt.LoadLibrary("")
+	}
+	return library
 }
 
-func run(messages []client.Message) {
-	// 创建标准输入扫描器
-	scanner := bufio.NewScanner(os.Stdin)
+// initTemplates 加载用户提示词模板，每个模板对应一个对话命令
+func initTemplates() *prompt.Library {
+	templates, err := prompt.LoadTemplates(templateDir())
+	if err != nil {
+		logger.Warn("加载提示词模板失败: %v", err)
+		fmt.Printf("警告: 加载提示词模板失败: %v\n", err)
+		templates, _ = prompt.LoadTemplates("")
+	}
+	return templates
+}
 
-	// 创建HTTP客户端
-	// 9.9 和 9.11 哪个大，这个问题为什么通常用来测试大模型
-	c := &http.Client{}
+// initProfile 解析 --profile 指定的配置档案，未指定时返回 nil
+func initProfile() *config.ProfileConfig {
+	if *profileName == "" {
+		return nil
+	}
+	profile, err := config.Current().Profile(*profileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	return &profile
+}
 
-	for {
-		fmt.Print("请输入问题：")
-		// 用户输入的问题
-		if !scanner.Scan() {
-			break
-		}
-		msg := strings.TrimSpace(scanner.Text())
-		if msg == "" {
-			continue
+// initSysRole 选择启动时使用的系统提示词，优先级为 --system 参数、配置档案的提示词、配置中的 default_prompt、内置默认提示词
+func initSysRole(library *prompt.Library, profile *config.ProfileConfig) *prompt.Prompt {
+	// 仅在明确要求时交互式输入系统提示词
+	if *askSystem {
+		global.InitSystemPrompt()
+		return &prompt.Prompt{
+			Name:        "custom",
+			Description: "启动时输入的系统提示词",
+			Content:     global.GetSystemPrompt(),
 		}
-		if msg == "!quit" {
-			break
-		}
-		messages = append(messages, client.Message{
-			Role:    client.UserRole,
-			Content: msg,
-		})
-
-		req := client.BuildStreamRequest(messages, 0.6)
+	}
 
-		// 发送请求
-		resp, err := c.Do(req)
-		if err != nil {
-			logger.Fatal("请求失败: %v", err)
-		}
+	name := *systemName
+	if name == "" && profile != nil {
+		name = profile.Prompt
+	}
+	if name == "" {
+		name = config.Current().DefaultPrompt
+	}
+	if name == "" {
+		return prompt.Default()
+	}
 
-		// 解析响应数据
-		responseBody, err := client.ParseStreamResponseWithCallback(resp, printContent)
-		if err != nil {
-			logger.Fatal("解析响应失败: %v", err)
-		}
+	p, ok := library.Get(name)
+	if !ok {
+		fmt.Printf("警告: 提示词 %s 不存在，使用默认系统提示词\n", name)
+		return prompt.Default()
+	}
+	return p
+}
 
-		// 打印响应结果
-		fmt.Printf("状态码: %d\n", resp.StatusCode)
-		fmt.Printf("模型: %s\n", responseBody.Model)
-
-		fmt.Printf("Token使用: 输入=%d, 输出=%d, 总计=%d\n",
-			responseBody.Usage.PromptTokens,
-			responseBody.Usage.CompletionTokens,
-			responseBody.Usage.TotalTokens)
-
-		// 将AI的回复添加到对话历史中
-		if len(responseBody.Choices) > 0 {
-			messages = append(messages, client.Message{
-				Role:    client.AssistantRole,
-				Content: responseBody.Choices[0].Message.Content,
-			})
-		}
+// initEditor 加载输入历史并创建行编辑器
+func initEditor() *terminal.Editor {
+	historyConf := config.Current().History
+	h, err := history.Load(env.SparrowCliHome+"/history", history.Options{
+		MaxEntries:    historyConf.MaxEntries,
+		MaxEntryBytes: historyConf.MaxEntryBytes,
+		IgnoreSpace:   historyConf.IgnoreSpace,
+	})
+	if err != nil {
+		// 历史文件损坏时不影响对话，仅使用内存中的历史
+		logger.Warn("加载输入历史失败: %v", err)
+		h, _ = history.Load("", history.Options{})
 	}
+	editor := terminal.NewEditor(h)
+	editor.Completer = attach.CompletePath
+	return editor
 }
 
 func main() {
+	// 作为代码块的沙箱进程启动时在此完成隔离并执行解释器，不会返回
+	codeblock.InitSandbox()
+
+	flag.Usage = usage
+	flag.Parse()
+
 	// 初始化项目家目录
 	initProjEnv()
 
+	// 执行子命令
+	if flag.NArg() > 0 {
+		os.Exit(runSubcommand(flag.Args()))
+	}
+
+	// 首次启动时运行配置向导
+	if !file.IsExist(config.HomeConfigPath()) && terminal.IsTerminal(os.Stdin) {
+		if err := runSetup(); err != nil {
+			fmt.Fprintf(os.Stderr, "配置向导: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// 加载配置文件
-	config.LoadConfig()
+	if err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	if len(config.Current().Models) == 0 {
+		fmt.Fprintf(os.Stderr, "尚未配置任何模型，请运行 %s setup 或编辑 %s\n", os.Args[0], config.HomeConfigPath())
+		os.Exit(1)
+	}
+	if _, ok := config.Current().DefaultModel(); !ok {
+		fmt.Fprintln(os.Stderr, "提示: 配置中的模型都由项目配置设置地址，不会自动使用，请通过 --profile 或提示词的 model 明确选择")
+	}
 
 	// 加载组件
 	initializationCtx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	initComponents(initializationCtx)
 	cancel()
 
-	// 请求信息
-	var messages []client.Message
+	// 加载提示词库
+	library := initPromptLibrary()
+
+	// 加载提示词模板
+	templates := initTemplates()
+
+	// 解析配置档案
+	profile := initProfile()
 
 	// 初始化角色
-	messages = initSysRole(messages)
+	sysPrompt := initSysRole(library, profile)
+
+	// 录制或回放模型请求
+	recorder := initFixture()
 
 	// 启动对话
-	run(messages)
+	run(library, templates, sysPrompt, profile)
+
+	if recorder != nil {
+		if err := recorder.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "写入录制文件失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
-func printContent(content string, isFinished bool) {
-	fmt.Print(content)
+// printContent 返回流式响应的回调函数，将增量内容写入渲染器
+func printContent(renderer *markdown.Renderer) func(content string, isFinished bool) {
+	return func(content string, isFinished bool) {
+		if _, err := renderer.WriteString(content); err != nil {
+			logger.Warn("输出回答失败: %v", err)
+		}
+	}
 }
@@ -0,0 +1,234 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+)
+
+// langSpec 语言的高亮规则
+type langSpec struct {
+	keywords      map[string]bool
+	lineComments  []string
+	blockComment  [2]string
+	quotes        string
+	caseSensitive bool
+}
+
+// highlighter 代码块语法高亮器，跨行记录块注释状态
+type highlighter struct {
+	spec    *langSpec
+	inBlock bool
+}
+
+var (
+	cLikeKeywords = "break case continue default do else for goto if return switch while const static struct enum union typedef sizeof void int long short char float double unsigned signed extern inline true false NULL"
+
+	langSpecs = map[string]*langSpec{
+		"go": {
+			keywords:      words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var true false nil iota any error string int int8 int16 int32 int64 uint uint8 uint16 uint32 uint64 float32 float64 bool byte rune"),
+			lineComments:  []string{"//"},
+			blockComment:  [2]string{"/*", "*/"},
+			quotes:        "\"'`",
+			caseSensitive: true,
+		},
+		"python": {
+			keywords:      words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield True False None self"),
+			lineComments:  []string{"#"},
+			quotes:        "\"'",
+			caseSensitive: true,
+		},
+		"javascript": {
+			keywords:      words("async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return super switch this throw try typeof var void while yield true false null undefined interface type implements enum readonly private public protected"),
+			lineComments:  []string{"//"},
+			blockComment:  [2]string{"/*", "*/"},
+			quotes:        "\"'`",
+			caseSensitive: true,
+		},
+		"java": {
+			keywords:      words("abstract assert boolean break byte case catch char class const continue default do double else enum extends final finally float for if implements import instanceof int interface long native new package private protected public return short static super switch synchronized this throw throws try void volatile while true false null var record"),
+			lineComments:  []string{"//"},
+			blockComment:  [2]string{"/*", "*/"},
+			quotes:        "\"'",
+			caseSensitive: true,
+		},
+		"c": {
+			keywords:      words(cLikeKeywords + " include define ifdef ifndef endif class namespace template typename public private protected virtual new delete this nullptr auto bool using"),
+			lineComments:  []string{"//"},
+			blockComment:  [2]string{"/*", "*/"},
+			quotes:        "\"'",
+			caseSensitive: true,
+		},
+		"rust": {
+			keywords:      words("as async await break const continue crate dyn else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while Some None Ok Err"),
+			lineComments:  []string{"//"},
+			blockComment:  [2]string{"/*", "*/"},
+			quotes:        "\"",
+			caseSensitive: true,
+		},
+		"shell": {
+			keywords:      words("if then else elif fi for in do done while until case esac function return local export echo exit set unset source"),
+			lineComments:  []string{"#"},
+			quotes:        "\"'",
+			caseSensitive: true,
+		},
+		"sql": {
+			keywords:      words("select from where and or not insert into values update set delete create table drop alter index join left right inner outer on group by order having limit offset as distinct union all null is in like between case when then else end primary key foreign references default"),
+			lineComments:  []string{"--"},
+			blockComment:  [2]string{"/*", "*/"},
+			quotes:        "'\"",
+			caseSensitive: false,
+		},
+		"yaml": {
+			keywords:      words("true false null yes no on off"),
+			lineComments:  []string{"#"},
+			quotes:        "\"'",
+			caseSensitive: true,
+		},
+		"json": {
+			keywords:      words("true false null"),
+			quotes:        "\"",
+			caseSensitive: true,
+		},
+	}
+
+	// langAliases 代码块语言标记到高亮规则的映射
+	langAliases = map[string]string{
+		"golang": "go", "py": "python", "python3": "python",
+		"js": "javascript", "jsx": "javascript", "ts": "javascript", "tsx": "javascript", "typescript": "javascript",
+		"cpp": "c", "c++": "c", "cc": "c", "h": "c", "hpp": "c",
+		"rs": "rust", "sh": "shell", "bash": "shell", "zsh": "shell", "console": "shell",
+		"yml": "yaml", "mysql": "sql", "postgresql": "sql", "sqlite": "sql",
+	}
+)
+
+// newHighlighter 根据代码块语言创建高亮器，未知语言不做高亮
+func newHighlighter(lang string) *highlighter {
+	lang = strings.ToLower(lang)
+	if alias, ok := langAliases[lang]; ok {
+		lang = alias
+	}
+	return &highlighter{spec: langSpecs[lang]}
+}
+
+// highlight 高亮一行代码
+func (h *highlighter) highlight(line string) string {
+	if h == nil || h.spec == nil {
+		return line
+	}
+	spec := h.spec
+
+	var sb strings.Builder
+	i := 0
+	for i < len(line) {
+		// 块注释内部
+		if h.inBlock {
+			end := strings.Index(line[i:], spec.blockComment[1])
+			if end < 0 {
+				sb.WriteString(colorGray + line[i:] + styleFgReset)
+				return sb.String()
+			}
+			end += i + len(spec.blockComment[1])
+			sb.WriteString(colorGray + line[i:end] + styleFgReset)
+			h.inBlock = false
+			i = end
+			continue
+		}
+
+		rest := line[i:]
+
+		// 块注释开始
+		if spec.blockComment[0] != "" && strings.HasPrefix(rest, spec.blockComment[0]) {
+			h.inBlock = true
+			sb.WriteString(colorGray + spec.blockComment[0])
+			sb.WriteString(styleFgReset)
+			i += len(spec.blockComment[0])
+			continue
+		}
+
+		// 行注释
+		if hasAnyPrefix(rest, spec.lineComments) {
+			sb.WriteString(colorGray + rest + styleFgReset)
+			return sb.String()
+		}
+
+		c := line[i]
+
+		// 字符串
+		if strings.IndexByte(spec.quotes, c) >= 0 {
+			end := i + 1
+			for end < len(line) && line[end] != c {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(line) {
+				end++
+			} else {
+				end = len(line)
+			}
+			sb.WriteString(colorGreen + line[i:end] + styleFgReset)
+			i = end
+			continue
+		}
+
+		// 数字
+		if c >= '0' && c <= '9' && (i == 0 || !isIdentByte(line[i-1])) {
+			end := i
+			for end < len(line) && (isIdentByte(line[end]) || line[end] == '.') {
+				end++
+			}
+			sb.WriteString(colorYellow + line[i:end] + styleFgReset)
+			i = end
+			continue
+		}
+
+		// 标识符与关键字
+		if isIdentByte(c) {
+			end := i
+			for end < len(line) && isIdentByte(line[end]) {
+				end++
+			}
+			word := line[i:end]
+			lookup := word
+			if !spec.caseSensitive {
+				lookup = strings.ToLower(word)
+			}
+			if spec.keywords[lookup] {
+				sb.WriteString(colorMagenta + word + styleFgReset)
+			} else {
+				sb.WriteString(word)
+			}
+			i = end
+			continue
+		}
+
+		sb.WriteByte(c)
+		i++
+	}
+	return sb.String()
+}
+
+// words 将空格分隔的关键字列表转换为集合
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+// hasAnyPrefix 判断字符串是否以任一前缀开头
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdentByte 判断字节是否可以出现在标识符中
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || b < 0x80 && (unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b)))
+}
@@ -0,0 +1,193 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// inlineStyle 成对出现的行内强调标记
+type inlineStyle struct {
+	marker string
+	on     string
+	off    string
+}
+
+// inlineStyles 按匹配优先级排列的行内强调标记
+var inlineStyles = []inlineStyle{
+	{"**", styleBold, styleBoldOff},
+	{"__", styleBold, styleBoldOff},
+	{"~~", styleStrike, styleStrikeOff},
+	{"*", styleItalic, styleItalicOff},
+	{"_", styleItalic, styleItalicOff},
+}
+
+// renderInline 渲染行内代码、粗体、斜体、删除线与链接
+func renderInline(s string) string {
+	var sb strings.Builder
+	i := 0
+
+	for i < len(s) {
+		c := s[i]
+
+		// 转义字符
+		if c == '\\' && i+1 < len(s) && isPunct(s[i+1]) {
+			sb.WriteByte(s[i+1])
+			i += 2
+			continue
+		}
+
+		// 行内代码，内部不再解析
+		if c == '`' {
+			n := countRun(s[i:], '`')
+			ticks := s[i : i+n]
+			if end := strings.Index(s[i+n:], ticks); end >= 0 {
+				sb.WriteString(colorYellow + s[i+n:i+n+end] + styleFgReset)
+				i += n + end + n
+				continue
+			}
+			sb.WriteString(ticks)
+			i += n
+			continue
+		}
+
+		// 图片与链接
+		if c == '[' || (c == '!' && strings.HasPrefix(s[i:], "![")) {
+			if text, url, n, ok := parseLink(s[i:]); ok {
+				if c == '!' {
+					sb.WriteString(styleDim + "[图片: " + text + "]" + styleBoldOff)
+				} else {
+					sb.WriteString(styleUnderline + colorBlue + renderInline(text) + styleFgReset + styleUnderOff)
+					if url != text {
+						sb.WriteString(styleDim + " (" + url + ")" + styleBoldOff)
+					}
+				}
+				i += n
+				continue
+			}
+		}
+
+		// 强调标记
+		if rendered, n, ok := renderEmphasis(s, i); ok {
+			sb.WriteString(rendered)
+			i += n
+			continue
+		}
+
+		sb.WriteByte(c)
+		i++
+	}
+
+	return sb.String()
+}
+
+// renderEmphasis 尝试在位置 i 处匹配成对的强调标记。
+// return 渲染结果、消耗的字节数以及是否匹配成功。
+func renderEmphasis(s string, i int) (string, int, bool) {
+	for _, style := range inlineStyles {
+		if !strings.HasPrefix(s[i:], style.marker) {
+			continue
+		}
+		m := len(style.marker)
+		start := i + m
+		// 标记之后紧跟空白时不视为强调
+		if start >= len(s) || s[start] == ' ' {
+			return "", 0, false
+		}
+		// 下划线只在单词边界生效，避免误伤 snake_case
+		if style.marker[0] == '_' && i > 0 && isWordByte(s[i-1]) {
+			return "", 0, false
+		}
+
+		end := findClosing(s, start+1, style.marker)
+		if end < 0 {
+			continue
+		}
+		return style.on + renderInline(s[start:end]) + style.off, end + m - i, true
+	}
+	return "", 0, false
+}
+
+// findClosing 从 start 开始查找闭合标记，返回其位置，找不到时返回 -1
+func findClosing(s string, start int, marker string) int {
+	for j := start; j+len(marker) <= len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] == '`' {
+			// 跳过行内代码
+			n := countRun(s[j:], '`')
+			if end := strings.Index(s[j+n:], s[j:j+n]); end >= 0 {
+				j += n + end + n - 1
+				continue
+			}
+		}
+		if !strings.HasPrefix(s[j:], marker) || s[j-1] == ' ' {
+			continue
+		}
+		// 单个 * 或 _ 不能匹配到双标记的一部分
+		after := j + len(marker)
+		if len(marker) == 1 && after < len(s) && s[after] == marker[0] {
+			j++
+			continue
+		}
+		if marker[0] == '_' && after < len(s) && isWordByte(s[after]) {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// parseLink 解析 [text](url) 或 ![alt](url)。
+// return 链接文本、地址、消耗的字节数以及是否解析成功。
+func parseLink(s string) (string, string, int, bool) {
+	offset := 0
+	if strings.HasPrefix(s, "!") {
+		offset = 1
+	}
+	closeText := strings.Index(s[offset:], "](")
+	if closeText < 0 {
+		return "", "", 0, false
+	}
+	closeText += offset
+	closeURL := strings.IndexByte(s[closeText+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	closeURL += closeText + 2
+
+	text := s[offset+1 : closeText]
+	url := s[closeText+2 : closeURL]
+	if strings.ContainsAny(url, " \t") {
+		return "", "", 0, false
+	}
+	return text, url, closeURL + 1, true
+}
+
+// countRun 统计字符串开头连续出现的字符 c 的个数
+func countRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// isWordByte 判断字节是否属于单词字符
+func isWordByte(b byte) bool {
+	return b == '_' || b >= 0x80 || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+// isPunct 判断字节是否为可转义的 ASCII 标点
+func isPunct(b byte) bool {
+	return b < 0x80 && unicode.IsPunct(rune(b)) || b == '`' || b == '*' || b == '_' || b == '|' || b == '~'
+}
+
+// stripANSI 去除字符串中的 ANSI 控制序列
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
@@ -0,0 +1,260 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sparrow-cli/terminal"
+	"strings"
+)
+
+// ANSI 控制序列
+const (
+	styleReset     = "\x1b[0m"
+	styleBold      = "\x1b[1m"
+	styleDim       = "\x1b[2m"
+	styleItalic    = "\x1b[3m"
+	styleUnderline = "\x1b[4m"
+	styleStrike    = "\x1b[9m"
+	styleBoldOff   = "\x1b[22m"
+	styleItalicOff = "\x1b[23m"
+	styleUnderOff  = "\x1b[24m"
+	styleStrikeOff = "\x1b[29m"
+	styleFgReset   = "\x1b[39m"
+	colorRed       = "\x1b[31m"
+	colorGreen     = "\x1b[32m"
+	colorYellow    = "\x1b[33m"
+	colorBlue      = "\x1b[34m"
+	colorMagenta   = "\x1b[35m"
+	colorCyan      = "\x1b[36m"
+	colorGray      = "\x1b[90m"
+)
+
+var (
+	headingPattern      = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	quotePattern        = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	unorderedPattern    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedPattern      = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	tableDividerPattern = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+)
+
+// FencePattern 匹配去掉缩进后的围栏代码块开始行，第一个分组为围栏，第二个分组为语言标记
+var FencePattern = regexp.MustCompile("^(`{3,}|~{3,})\\s*([\\w#+.-]*)")
+
+// Renderer 增量 Markdown 渲染器。
+// 流式输出的内容可以按任意大小的片段写入，渲染器在收到完整的行后进行渲染；
+// 设置了终端宽度时，代码块与表格之外未完成的行会先原样预览，收到换行后擦除预览并输出渲染结果。
+// 表格在整个表格结束后统一对齐输出，未完成的最后一行在 Flush 时输出。
+type Renderer struct {
+	out     io.Writer
+	enabled bool
+	width   func() int // 终端列数，为 nil 时不预览未完成的行
+
+	pending []byte   // 尚未收到换行符的内容
+	shown   int      // pending 中已经预览输出的字节数
+	table   []string // 缓冲中的表格行
+
+	inFence bool         // 是否处于围栏代码块中
+	fence   string       // 开始围栏的标记
+	hl      *highlighter // 当前代码块的语法高亮器
+}
+
+// NewRenderer 创建 Markdown 渲染器。
+// param out 为渲染结果的输出目标。
+// param enabled 为 false 时原样输出内容，不做任何渲染。
+//
+// return 创建完成的渲染器。
+func NewRenderer(out io.Writer, enabled bool) *Renderer {
+	return &Renderer{out: out, enabled: enabled}
+}
+
+// SetWidth 设置获取终端列数的函数，设置后未完成的行会先预览输出，避免长段落在换行前没有任何输出。
+// 预览需要在收到换行后擦除重绘，只应在输出到终端时设置。
+func (r *Renderer) SetWidth(width func() int) {
+	r.width = width
+}
+
+// Write 写入一段 Markdown 文本，实现 io.Writer 接口
+func (r *Renderer) Write(p []byte) (int, error) {
+	if !r.enabled {
+		return r.out.Write(p)
+	}
+
+	r.pending = append(r.pending, p...)
+	for {
+		i := bytes.IndexByte(r.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if err := r.clearPreview(); err != nil {
+			return 0, err
+		}
+		line := strings.TrimSuffix(string(r.pending[:i]), "\r")
+		r.pending = r.pending[i+1:]
+		if err := r.renderLine(line); err != nil {
+			return 0, err
+		}
+	}
+	if err := r.preview(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteString 写入一段 Markdown 文本
+func (r *Renderer) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
+
+// Flush 输出所有缓冲的内容并重置渲染状态，应在一次回答结束后调用
+func (r *Renderer) Flush() error {
+	if !r.enabled {
+		return nil
+	}
+
+	if len(r.pending) > 0 {
+		if err := r.clearPreview(); err != nil {
+			return err
+		}
+		line := string(r.pending)
+		r.pending = nil
+		if err := r.renderLine(line); err != nil {
+			return err
+		}
+	}
+	if err := r.flushTable(); err != nil {
+		return err
+	}
+
+	r.inFence = false
+	r.fence = ""
+	r.hl = nil
+	return nil
+}
+
+// renderLine 渲染一行完整的 Markdown 文本
+func (r *Renderer) renderLine(line string) error {
+	trimmed := strings.TrimSpace(line)
+
+	// 代码块内部
+	if r.inFence {
+		if strings.HasPrefix(trimmed, r.fence) && strings.Trim(trimmed, r.fence[:1]) == "" {
+			r.inFence = false
+			r.hl = nil
+			return r.emit(styleDim + trimmed + styleReset)
+		}
+		return r.emit(r.hl.highlight(line))
+	}
+
+	// 代码块开始
+	if m := FencePattern.FindStringSubmatch(trimmed); m != nil {
+		if err := r.flushTable(); err != nil {
+			return err
+		}
+		r.inFence = true
+		r.fence = m[1]
+		r.hl = newHighlighter(m[2])
+		return r.emit(styleDim + trimmed + styleReset)
+	}
+
+	// 表格行先缓冲，等表格结束后统一计算列宽
+	if strings.HasPrefix(trimmed, "|") {
+		r.table = append(r.table, trimmed)
+		return nil
+	}
+	if err := r.flushTable(); err != nil {
+		return err
+	}
+
+	return r.emit(renderBlock(line))
+}
+
+// preview 原样输出未完成的行中尚未预览的部分。
+// 代码块中的行需要整行高亮，以 | 开头的行可能是表格，以 ` 或 ~ 开头的行可能是围栏，这些行都等收到换行后再输出。
+func (r *Renderer) preview() error {
+	if r.width == nil || r.inFence || r.shown >= len(r.pending) {
+		return nil
+	}
+	trimmed := bytes.TrimLeft(r.pending, " \t")
+	if len(trimmed) == 0 || bytes.IndexByte([]byte("|`~"), trimmed[0]) >= 0 {
+		return nil
+	}
+	_, err := r.out.Write(r.pending[r.shown:])
+	r.shown = len(r.pending)
+	return err
+}
+
+// clearPreview 擦除已经预览的内容，光标回到预览开始的位置
+func (r *Renderer) clearPreview() error {
+	if r.shown == 0 {
+		return nil
+	}
+	shown := string(r.pending[:r.shown])
+	r.shown = 0
+
+	// 预览超过终端宽度时会折行，需要先上移到预览的第一行
+	seq := "\r"
+	if width := r.width(); width > 0 {
+		if rows := (terminal.StringWidth(shown) - 1) / width; rows > 0 {
+			seq += fmt.Sprintf("\x1b[%dA", rows)
+		}
+	}
+	_, err := io.WriteString(r.out, seq+"\x1b[J")
+	return err
+}
+
+// emit 输出一行渲染结果
+func (r *Renderer) emit(s string) error {
+	_, err := io.WriteString(r.out, s+"\n")
+	return err
+}
+
+// renderBlock 渲染标题、引用、列表、分隔线等块级元素
+func renderBlock(line string) string {
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		switch len(m[1]) {
+		case 1:
+			return styleBold + styleUnderline + colorMagenta + renderInline(m[2]) + styleReset
+		case 2:
+			return styleBold + colorMagenta + renderInline(m[2]) + styleReset
+		default:
+			return styleBold + colorBlue + renderInline(m[2]) + styleReset
+		}
+	}
+
+	if isHorizontalRule(line) {
+		return styleDim + strings.Repeat("─", 40) + styleReset
+	}
+
+	if m := quotePattern.FindStringSubmatch(line); m != nil {
+		return colorGray + "│ " + styleFgReset + styleItalic + renderBlock(m[1]) + styleItalicOff
+	}
+
+	if m := unorderedPattern.FindStringSubmatch(line); m != nil {
+		item := m[2]
+		bullet := colorCyan + "•" + styleFgReset + " "
+		switch {
+		case strings.HasPrefix(item, "[ ] "):
+			bullet, item = colorCyan+"☐"+styleFgReset+" ", item[4:]
+		case strings.HasPrefix(item, "[x] "), strings.HasPrefix(item, "[X] "):
+			bullet, item = colorGreen+"☑"+styleFgReset+" ", item[4:]
+		}
+		return m[1] + bullet + renderInline(item)
+	}
+
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + colorCyan + m[2] + "." + styleFgReset + " " + renderInline(m[3])
+	}
+
+	return renderInline(line)
+}
+
+// isHorizontalRule 判断是否为分隔线（---、***、___）
+func isHorizontalRule(line string) bool {
+	trimmed := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	if len(trimmed) < 3 {
+		return false
+	}
+	return strings.Trim(trimmed, trimmed[:1]) == "" && strings.ContainsAny(trimmed[:1], "-*_")
+}
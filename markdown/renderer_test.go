@@ -0,0 +1,100 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = "# 标题\n" +
+	"一段 **粗体** 与 *斜体* 以及 `code` 和 snake_case_name\n" +
+	"- [x] 完成\n" +
+	"1. 第一步\n" +
+	"> 引用\n" +
+	"| 名称 | 数量 |\n" +
+	"| :--- | ---: |\n" +
+	"| 苹果 | 3 |\n" +
+	"| pear | 12 |\n" +
+	"```go\n" +
+	"func main() { // 入口\n" +
+	"```\n" +
+	"结尾没有换行"
+
+func render(chunks []string) string {
+	var sb strings.Builder
+	r := NewRenderer(&sb, true)
+	for _, chunk := range chunks {
+		_, _ = r.WriteString(chunk)
+	}
+	_ = r.Flush()
+	return sb.String()
+}
+
+func TestRendererChunked(t *testing.T) {
+	whole := render([]string{sample})
+
+	// 按单个字节切分写入，结果应与整体写入一致
+	var chunks []string
+	for i := 0; i < len(sample); i++ {
+		chunks = append(chunks, sample[i:i+1])
+	}
+	if chunked := render(chunks); chunked != whole {
+		t.Errorf("chunked output differs:\n%q\n%q", chunked, whole)
+	}
+
+	plain := stripANSI(whole)
+	for _, want := range []string{
+		"标题\n",
+		"一段 粗体 与 斜体 以及 code 和 snake_case_name\n",
+		"☑ 完成\n",
+		"│ 名称 │ 数量 │\n",
+		"│ 苹果 │    3 │\n",
+		"│ pear │   12 │\n",
+		"func main() { // 入口\n",
+		"结尾没有换行\n",
+	} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("output missing %q:\n%s", want, plain)
+		}
+	}
+}
+
+func TestRendererRaw(t *testing.T) {
+	var sb strings.Builder
+	r := NewRenderer(&sb, false)
+	_, _ = r.WriteString(sample)
+	_ = r.Flush()
+	if sb.String() != sample {
+		t.Errorf("raw output = %q, want %q", sb.String(), sample)
+	}
+}
+
+func TestRendererPreview(t *testing.T) {
+	var sb strings.Builder
+	r := NewRenderer(&sb, true)
+	r.SetWidth(func() int { return 10 })
+
+	// 没有换行的长段落应立即输出，而不是等到换行
+	_, _ = r.WriteString("一段很长的 **粗体")
+	if got := sb.String(); got != "一段很长的 **粗体" {
+		t.Fatalf("preview output = %q", got)
+	}
+	_, _ = r.WriteString("** 文本\n")
+	// 预览占用 17 列，在 10 列的终端中折成两行，需要上移一行后擦除再输出渲染结果
+	want := "一段很长的 **粗体\r\x1b[1A\x1b[J" + renderBlock("一段很长的 **粗体** 文本") + "\n"
+	if got := sb.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// 代码块与可能是表格或围栏的行不预览
+	sb.Reset()
+	for _, chunk := range []string{"| 表格", "\n", "```go\n", "func main() {"} {
+		_, _ = r.WriteString(chunk)
+		if strings.HasSuffix(sb.String(), chunk) {
+			t.Errorf("chunk %q should not be previewed: %q", chunk, sb.String())
+		}
+	}
+	_ = r.Flush()
+	if plain := stripANSI(sb.String()); !strings.HasSuffix(plain, "func main() {\n") {
+		t.Errorf("flushed output = %q", plain)
+	}
+}
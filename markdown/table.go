@@ -0,0 +1,160 @@
+package markdown
+
+import (
+	"sparrow-cli/terminal"
+	"strings"
+)
+
+// align 表格列的对齐方式
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+	alignRight
+)
+
+// flushTable 输出缓冲中的表格，缓冲内容不是合法表格时按普通文本输出
+func (r *Renderer) flushTable() error {
+	if len(r.table) == 0 {
+		return nil
+	}
+	rows := r.table
+	r.table = nil
+
+	if len(rows) < 2 || !tableDividerPattern.MatchString(rows[1]) {
+		for _, row := range rows {
+			if err := r.emit(renderInline(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	aligns := parseAligns(rows[1])
+	cells := make([][]string, 0, len(rows)-1)
+	for i, row := range rows {
+		if i == 1 {
+			continue
+		}
+		cells = append(cells, splitRow(row))
+	}
+
+	// 计算列数与列宽
+	cols := len(aligns)
+	for _, row := range cells {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	widths := make([]int, cols)
+	rendered := make([][]string, len(cells))
+	for i, row := range cells {
+		rendered[i] = make([]string, cols)
+		for j := 0; j < cols; j++ {
+			if j < len(row) {
+				rendered[i][j] = renderInline(row[j])
+			}
+			if w := terminal.StringWidth(stripANSI(rendered[i][j])); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	if err := r.emit(styleDim + tableBorder("┌", "┬", "┐", widths) + styleReset); err != nil {
+		return err
+	}
+	for i, row := range rendered {
+		var sb strings.Builder
+		sb.WriteString(styleDim + "│" + styleReset)
+		for j, cell := range row {
+			a := alignLeft
+			if j < len(aligns) {
+				a = aligns[j]
+			}
+			if i == 0 {
+				cell = styleBold + cell + styleBoldOff
+			}
+			sb.WriteString(" " + pad(cell, widths[j], a) + " " + styleDim + "│" + styleReset)
+		}
+		if err := r.emit(sb.String()); err != nil {
+			return err
+		}
+		if i == 0 {
+			if err := r.emit(styleDim + tableBorder("├", "┼", "┤", widths) + styleReset); err != nil {
+				return err
+			}
+		}
+	}
+	return r.emit(styleDim + tableBorder("└", "┴", "┘", widths) + styleReset)
+}
+
+// splitRow 将表格行拆分为单元格，支持 \| 转义
+func splitRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+
+	var cells []string
+	var sb strings.Builder
+	for i := 0; i < len(row); i++ {
+		if row[i] == '\\' && i+1 < len(row) && row[i+1] == '|' {
+			sb.WriteByte('|')
+			i++
+			continue
+		}
+		if row[i] == '|' {
+			cells = append(cells, strings.TrimSpace(sb.String()))
+			sb.Reset()
+			continue
+		}
+		sb.WriteByte(row[i])
+	}
+	return append(cells, strings.TrimSpace(sb.String()))
+}
+
+// parseAligns 根据分隔行解析每列的对齐方式
+func parseAligns(divider string) []align {
+	parts := splitRow(divider)
+	aligns := make([]align, len(parts))
+	for i, part := range parts {
+		left := strings.HasPrefix(part, ":")
+		right := strings.HasSuffix(part, ":")
+		switch {
+		case left && right:
+			aligns[i] = alignCenter
+		case right:
+			aligns[i] = alignRight
+		default:
+			aligns[i] = alignLeft
+		}
+	}
+	return aligns
+}
+
+// tableBorder 生成表格边框线
+func tableBorder(left, middle, right string, widths []int) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = strings.Repeat("─", w+2)
+	}
+	return left + strings.Join(parts, middle) + right
+}
+
+// pad 按对齐方式将单元格填充到指定显示宽度
+func pad(cell string, width int, a align) string {
+	gap := width - terminal.StringWidth(stripANSI(cell))
+	if gap <= 0 {
+		return cell
+	}
+	switch a {
+	case alignRight:
+		return strings.Repeat(" ", gap) + cell
+	case alignCenter:
+		return strings.Repeat(" ", gap/2) + cell + strings.Repeat(" ", gap-gap/2)
+	default:
+		return cell + strings.Repeat(" ", gap)
+	}
+}
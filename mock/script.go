@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Script 脚本化的回答规则，按顺序匹配，使用第一条命中的规则，都不命中时回显用户消息
+type Script struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule 一条回答规则，所有已设置的条件都满足时命中
+type Rule struct {
+	Match   string        `yaml:"match"`   // 匹配最后一条用户消息的正则表达式
+	Model   string        `yaml:"model"`   // 只匹配请求该模型的请求
+	Reply   string        `yaml:"reply"`   // 回答内容
+	Status  int           `yaml:"status"`  // 非 0 时返回该状态码的错误
+	Error   string        `yaml:"error"`   // 错误信息，与 status 一起使用
+	Latency time.Duration `yaml:"latency"` // 额外的响应延迟
+
+	pattern *regexp.Regexp
+}
+
+// LoadScript 读取并编译脚本文件
+// param path 为脚本文件路径。
+//
+// return 脚本和可能的错误。
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取脚本文件失败: %w", err)
+	}
+	var s Script
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("解析脚本文件失败 %s: %w", path, err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("脚本文件 %s 有误: %w", path, err)
+	}
+	return &s, nil
+}
+
+// compile 编译规则中的正则表达式
+func (s *Script) compile() error {
+	for i := range s.Rules {
+		r := &s.Rules[i]
+		if r.Match == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(r.Match)
+		if err != nil {
+			return fmt.Errorf("rules[%d].match 不是有效的正则表达式: %w", i, err)
+		}
+		r.pattern = pattern
+	}
+	return nil
+}
+
+// find 返回第一条命中的规则，没有命中时返回 nil
+func (s *Script) find(model, message string) *Rule {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Rules {
+		r := &s.Rules[i]
+		if r.Model != "" && r.Model != model {
+			continue
+		}
+		if r.pattern != nil && !r.pattern.MatchString(message) {
+			continue
+		}
+		return r
+	}
+	return nil
+}
@@ -0,0 +1,447 @@
+// Package mock 提供兼容 OpenAI 接口的模拟服务，用于演示、离线开发与集成测试。
+// 支持 /v1/chat/completions（流式与非流式）、/v1/models 与 /v1/embeddings，
+// 默认回显用户消息，也可以通过脚本指定回答，并模拟延迟、错误与限流。
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultModel 未指定模型列表时提供的模型
+const DefaultModel = "mock-model"
+
+const (
+	defaultEmbeddingDimensions = 8 // 默认的向量维度
+	chunkRunes                 = 4 // 流式回答每个数据块包含的字符数
+)
+
+// Options 模拟服务的设置
+type Options struct {
+	Models     []string      // 可用的模型，请求其他模型时返回 404，为空时接受任意模型并在列表中显示 DefaultModel
+	Script     *Script       // 脚本化的回答规则，为 nil 时回显用户消息
+	APIKey     string        // 非空时要求请求携带该密钥
+	Latency    time.Duration // 每个请求返回响应前的延迟
+	ChunkDelay time.Duration // 流式回答两个数据块之间的间隔
+	ErrorRate  float64       // 随机返回 500 错误的概率，取值 0 到 1
+	RateLimit  int           // 每个时间窗口内允许的请求数，超出时返回 429，为 0 时不限流
+	RateWindow time.Duration // 限流的时间窗口，默认 1 分钟
+	Dimensions int           // 向量维度，默认 8
+}
+
+// Server 模拟服务，实现 http.Handler
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+
+	lock     sync.Mutex
+	requests []time.Time // 当前时间窗口内的请求时间，用于限流
+	served   int         // 已处理的请求数，用于生成响应 ID
+}
+
+// New 创建模拟服务
+// param opts 为服务设置。
+//
+// return 模拟服务。
+func New(opts Options) *Server {
+	if opts.RateWindow <= 0 {
+		opts.RateWindow = time.Minute
+	}
+	if opts.Dimensions <= 0 {
+		opts.Dimensions = defaultEmbeddingDimensions
+	}
+
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	// 同时支持带与不带 /v1 前缀的路径，以兼容各服务商的地址写法
+	for _, prefix := range []string{"/v1", ""} {
+		s.mux.HandleFunc("POST "+prefix+"/chat/completions", s.handleChat)
+		s.mux.HandleFunc("GET "+prefix+"/models", s.handleModels)
+		s.mux.HandleFunc("POST "+prefix+"/embeddings", s.handleEmbeddings)
+	}
+	return s
+}
+
+// Models 返回服务提供的模型
+func (s *Server) Models() []string {
+	if len(s.opts.Models) == 0 {
+		return []string{DefaultModel}
+	}
+	return s.opts.Models
+}
+
+// ServeHTTP 依次检查密钥、限流与随机错误，然后分发请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.opts.APIKey != "" && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid_api_key", "Incorrect API key provided.")
+		return
+	}
+	if retryAfter, ok := s.allow(); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "rate_limit_exceeded", "Rate limit reached, please try again later.")
+		return
+	}
+	if s.opts.ErrorRate > 0 && rand.Float64() < s.opts.ErrorRate {
+		writeError(w, http.StatusInternalServerError, "server_error", "The server had an error while processing your request.")
+		return
+	}
+	if s.opts.Latency > 0 {
+		// 先读完请求体，服务器才会在客户端断开时取消请求的 context，等待才能提前结束
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if !sleep(r, s.opts.Latency) {
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized 检查 Bearer 令牌或 Azure 风格的 api-key 请求头
+func (s *Server) authorized(r *http.Request) bool {
+	if key := r.Header.Get("api-key"); key != "" {
+		return key == s.opts.APIKey
+	}
+	return r.Header.Get("Authorization") == "Bearer "+s.opts.APIKey
+}
+
+// allow 记录一次请求，超出限流时返回需要等待的时间
+func (s *Server) allow() (time.Duration, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.served++
+	if s.opts.RateLimit <= 0 {
+		return 0, true
+	}
+
+	now := time.Now()
+	expired := 0
+	for expired < len(s.requests) && now.Sub(s.requests[expired]) >= s.opts.RateWindow {
+		expired++
+	}
+	s.requests = s.requests[expired:]
+	if len(s.requests) >= s.opts.RateLimit {
+		return s.opts.RateWindow - now.Sub(s.requests[0]), false
+	}
+	s.requests = append(s.requests, now)
+	return 0, true
+}
+
+// nextID 生成响应 ID
+func (s *Server) nextID(prefix string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return fmt.Sprintf("%s-mock-%d", prefix, s.served)
+}
+
+// hasModel 判断服务是否提供该模型
+func (s *Server) hasModel(name string) bool {
+	if len(s.opts.Models) == 0 {
+		return true
+	}
+	for _, model := range s.opts.Models {
+		if model == name {
+			return true
+		}
+	}
+	return false
+}
+
+// chatRequest 对话请求中模拟服务关心的字段
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+
+	StreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options"`
+}
+
+// chatMessage 对话消息，content 可以是字符串或多模态片段数组
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text 返回消息中的文本内容，多模态消息只取文本片段
+func (m chatMessage) text() string {
+	var s string
+	if json.Unmarshal(m.Content, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(m.Content, &parts) != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// usage Token 使用情况
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// handleChat 处理对话请求
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "Invalid JSON body: "+err.Error())
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "'messages' must contain at least one message.")
+		return
+	}
+	if !s.hasModel(req.Model) {
+		writeError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("The model `%s` does not exist.", req.Model))
+		return
+	}
+
+	prompt := ""
+	promptTokens := 0
+	for _, m := range req.Messages {
+		text := m.text()
+		promptTokens += countTokens(text)
+		if m.Role == "user" {
+			prompt = text
+		}
+	}
+
+	reply := "你说: " + prompt
+	if rule := s.opts.Script.find(req.Model, prompt); rule != nil {
+		if !sleep(r, rule.Latency) {
+			return
+		}
+		if rule.Status != 0 {
+			message := rule.Error
+			if message == "" {
+				message = http.StatusText(rule.Status)
+			}
+			writeError(w, rule.Status, "scripted_error", message)
+			return
+		}
+		reply = rule.Reply
+	}
+
+	u := usage{PromptTokens: promptTokens, CompletionTokens: countTokens(reply)}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	id := s.nextID("chatcmpl")
+	if req.Stream {
+		var streamUsage *usage
+		if req.StreamOptions.IncludeUsage {
+			streamUsage = &u
+		}
+		s.streamChat(w, r, id, req.Model, reply, streamUsage)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   req.Model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": reply},
+			"finish_reason": "stop",
+		}},
+		"usage": u,
+	})
+}
+
+// streamChat 以 SSE 格式分块输出回答。与 OpenAI 一致，只在请求设置了 stream_options.include_usage 时
+// 额外输出一个选择项为空、携带 Token 使用情况的块，u 为 nil 时不输出
+func (s *Server) streamChat(w http.ResponseWriter, r *http.Request, id, model, reply string, u *usage) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	created := time.Now().Unix()
+	write := func(choices []map[string]any, u *usage) {
+		chunk := map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   model,
+			"choices": choices,
+		}
+		if u != nil {
+			chunk["usage"] = u
+		}
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	send := func(delta map[string]string, finishReason any) {
+		write([]map[string]any{{"index": 0, "delta": delta, "finish_reason": finishReason}}, nil)
+	}
+
+	send(map[string]string{"role": "assistant"}, nil)
+	for i, chunk := range splitRunes(reply, chunkRunes) {
+		if i > 0 && !sleep(r, s.opts.ChunkDelay) {
+			return
+		}
+		send(map[string]string{"content": chunk}, nil)
+	}
+	send(map[string]string{}, "stop")
+	if u != nil {
+		write([]map[string]any{}, u)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// handleModels 列出服务提供的模型
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	created := time.Now().Unix()
+	models := s.Models()
+	data := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		data = append(data, map[string]any{"id": model, "object": "model", "created": created, "owned_by": "sparrow-cli"})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+// handleEmbeddings 为每个输入生成由内容决定的单位向量，相同输入总是得到相同结果
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model string          `json:"model"`
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "Invalid JSON body: "+err.Error())
+		return
+	}
+	if !s.hasModel(req.Model) {
+		writeError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("The model `%s` does not exist.", req.Model))
+		return
+	}
+	var inputs []string
+	var single string
+	if json.Unmarshal(req.Input, &single) == nil {
+		inputs = []string{single}
+	} else if json.Unmarshal(req.Input, &inputs) != nil || len(inputs) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "'input' must be a string or an array of strings.")
+		return
+	}
+
+	tokens := 0
+	data := make([]map[string]any, 0, len(inputs))
+	for i, input := range inputs {
+		tokens += countTokens(input)
+		data = append(data, map[string]any{"object": "embedding", "index": i, "embedding": embed(input, s.opts.Dimensions)})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"object": "list",
+		"data":   data,
+		"model":  req.Model,
+		"usage":  map[string]int{"prompt_tokens": tokens, "total_tokens": tokens},
+	})
+}
+
+// embed 以输入的哈希作为随机种子生成单位向量
+func embed(input string, dimensions int) []float64 {
+	h := fnv.New64a()
+	h.Write([]byte(input))
+	r := rand.New(rand.NewPCG(h.Sum64(), uint64(dimensions)))
+
+	vector := make([]float64, dimensions)
+	norm := 0.0
+	for i := range vector {
+		vector[i] = r.NormFloat64()
+		norm += vector[i] * vector[i]
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+// countTokens 粗略估算 Token 数，每个字符计为一个
+func countTokens(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// splitRunes 按字符数切分字符串，不会切断多字节字符
+func splitRunes(s string, n int) []string {
+	runes := []rune(s)
+	chunks := make([]string, 0, len(runes)/n+1)
+	for len(runes) > n {
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
+
+// sleep 等待指定时间，请求被取消时返回 false
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出 OpenAI 格式的错误响应
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{"message": message, "type": errorType(status), "code": code},
+	})
+}
+
+// errorType 按状态码返回 OpenAI 的错误类型
+func errorType(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "requests"
+	case status >= 500:
+		return "server_error"
+	default:
+		return "invalid_request_error"
+	}
+}
@@ -0,0 +1,185 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sparrow-cli/client"
+	"sparrow-cli/global"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer 启动模拟服务并返回指向它的模型
+func newTestServer(t *testing.T, opts Options) (*httptest.Server, *global.Model) {
+	t.Helper()
+	server := httptest.NewServer(New(opts))
+	t.Cleanup(server.Close)
+	return server, &global.Model{Name: DefaultModel, ApiKey: "mock", URL: server.URL + "/v1/chat/completions"}
+}
+
+func TestChat(t *testing.T) {
+	_, model := newTestServer(t, Options{})
+	c := client.New(model)
+	messages := []client.Message{{Role: client.SysRole, Content: "你是助手"}, {Role: client.UserRole, Content: "你好，世界"}}
+
+	resp, err := c.Chat(context.Background(), messages, 0.7)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "你说: 你好，世界" {
+		t.Errorf("Chat() content = %q", got)
+	}
+	want := client.Usage{PromptTokens: 9, CompletionTokens: 9, TotalTokens: 18}
+	if resp.Usage != want {
+		t.Errorf("Chat() usage = %+v, want %+v", resp.Usage, want)
+	}
+
+	var chunks []string
+	resp, err = c.ChatStream(context.Background(), messages, 0.7, func(content string, isFinished bool) {
+		if content != "" {
+			chunks = append(chunks, content)
+		}
+	})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	if len(chunks) < 2 || strings.Join(chunks, "") != "你说: 你好，世界" {
+		t.Errorf("ChatStream() chunks = %q", chunks)
+	}
+	if resp.Choices[0].FinishReason != "stop" || resp.Usage != want {
+		t.Errorf("ChatStream() finish reason = %q, usage = %+v", resp.Choices[0].FinishReason, resp.Usage)
+	}
+}
+
+func TestScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.yaml")
+	script := `rules:
+  - match: 天气
+    reply: 今天晴，适合出门。
+  - model: broken
+    status: 503
+    error: overloaded
+`
+	if err := os.WriteFile(path, []byte(script), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadScript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, model := newTestServer(t, Options{Script: s})
+
+	resp, err := client.New(model).Chat(context.Background(), []client.Message{{Role: client.UserRole, Content: "明天天气怎么样"}}, 0)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "今天晴，适合出门。" {
+		t.Errorf("scripted reply = %q", got)
+	}
+
+	broken := &global.Model{Name: "broken", ApiKey: "mock", URL: server.URL + "/v1/chat/completions"}
+	_, err = client.New(broken).Chat(context.Background(), []client.Message{{Role: client.UserRole, Content: "你好"}}, 0)
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable || !strings.Contains(statusErr.Body, "overloaded") {
+		t.Errorf("scripted error = %v, want 503 StatusError", err)
+	}
+
+	os.WriteFile(path, []byte("rules:\n  - match: \"[\"\n"), 0600)
+	if _, err := LoadScript(path); err == nil {
+		t.Errorf("LoadScript() should reject invalid pattern")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	_, model := newTestServer(t, Options{RateLimit: 1, APIKey: "secret", Models: []string{DefaultModel}})
+	messages := []client.Message{{Role: client.UserRole, Content: "你好"}}
+	var statusErr *client.StatusError
+
+	unauthorized := *model
+	unauthorized.ApiKey = "wrong"
+	if _, err := client.New(&unauthorized).Chat(context.Background(), messages, 0); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong key error = %v, want 401", err)
+	}
+
+	model.ApiKey = "secret"
+	unknown := *model
+	unknown.Name = "gpt-4o"
+	if _, err := client.New(&unknown).Chat(context.Background(), messages, 0); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown model error = %v, want 404", err)
+	}
+	if _, err := client.New(model).Chat(context.Background(), messages, 0); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("rate limited error = %v, want 429", err)
+	}
+
+	_, model = newTestServer(t, Options{ErrorRate: 1})
+	if _, err := client.New(model).Chat(context.Background(), messages, 0); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("error rate error = %v, want 500", err)
+	}
+
+	_, model = newTestServer(t, Options{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.New(model).Chat(ctx, messages, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("latency error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestModelsAndEmbeddings(t *testing.T) {
+	server, _ := newTestServer(t, Options{Models: []string{"a", "b"}, Dimensions: 4})
+
+	resp, err := http.Get(server.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if len(list.Data) != 2 || list.Data[0].ID != "a" || list.Data[1].ID != "b" {
+		t.Errorf("models = %+v", list.Data)
+	}
+
+	embeddings := func(input string) [][]float64 {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/v1/embeddings", "application/json", strings.NewReader(`{"model":"a","input":`+input+`}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("embeddings status = %d", resp.StatusCode)
+		}
+		var body struct {
+			Data []struct {
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		vectors := make([][]float64, len(body.Data))
+		for i, d := range body.Data {
+			vectors[i] = d.Embedding
+		}
+		return vectors
+	}
+
+	batch := embeddings(`["你好","世界"]`)
+	if len(batch) != 2 || len(batch[0]) != 4 {
+		t.Fatalf("embeddings = %v", batch)
+	}
+	if single := embeddings(`"你好"`); !reflect.DeepEqual(single[0], batch[0]) {
+		t.Errorf("embeddings of same input differ: %v vs %v", single[0], batch[0])
+	}
+	if reflect.DeepEqual(batch[0], batch[1]) {
+		t.Errorf("embeddings of different inputs should differ")
+	}
+}
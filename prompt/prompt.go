@@ -0,0 +1,203 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sparrow-cli/file"
+	"sparrow-cli/global"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultName 内置默认提示词的名称
+const DefaultName = "default"
+
+// DefaultTemperature 提示词未指定温度时使用的默认值
+const DefaultTemperature = 0.6
+
+// Prompt 提示词库中的一条系统提示词或用户提示词模板
+type Prompt struct {
+	Name        string     `yaml:"name"`        // 提示词名称，为空时使用文件名
+	Description string     `yaml:"description"` // 提示词描述
+	Model       string     `yaml:"model"`       // 默认使用的模型，为空时沿用当前模型
+	Temperature *float64   `yaml:"temperature"` // 默认温度，为空时使用 DefaultTemperature
+	Vars        []Variable `yaml:"vars"`        // 用户声明的模板变量，使用前由 REPL 询问
+	Content     string     `yaml:"-"`           // 提示词正文，支持模板语法
+	Path        string     `yaml:"-"`           // 提示词文件路径，内置提示词为空
+}
+
+// Variable 模板中需要用户输入的变量
+type Variable struct {
+	Name    string `yaml:"name"`    // 变量名，在模板中通过 {{.变量名}} 引用
+	Prompt  string `yaml:"prompt"`  // 询问用户时显示的提示，为空时使用变量名
+	Default string `yaml:"default"` // 用户直接回车时使用的默认值
+}
+
+// Library 提示词库
+type Library struct {
+	dir     string
+	prompts map[string]*Prompt
+}
+
+// Default 返回内置的默认提示词
+func Default() *Prompt {
+	return &Prompt{
+		Name:        DefaultName,
+		Description: "内置默认系统提示词",
+		Content:     global.DefaultSystemPrompt,
+	}
+}
+
+// GetTemperature 返回提示词的温度，未设置时返回默认值
+func (p *Prompt) GetTemperature() float64 {
+	if p.Temperature == nil {
+		return DefaultTemperature
+	}
+	return *p.Temperature
+}
+
+// LoadLibrary 加载目录中的所有提示词文件（*.md、*.txt），目录不存在时返回只包含内置提示词的库。
+// 每个文件可以以 YAML front-matter 开头声明名称、描述、默认模型与参数，其余内容为提示词正文。
+// param dir 为提示词目录。
+//
+// return 加载完成的提示词库和可能的错误。
+func LoadLibrary(dir string) (*Library, error) {
+	lib := &Library{
+		dir:     dir,
+		prompts: map[string]*Prompt{DefaultName: Default()},
+	}
+	if err := lib.load(); err != nil {
+		return nil, err
+	}
+	return lib, nil
+}
+
+// LoadTemplates 加载用户提示词模板目录，格式与提示词库相同，但不包含内置提示词。
+// 每个模板对应一个同名的对话命令，例如 review.md 对应 /review。
+// param dir 为模板目录。
+//
+// return 加载完成的模板库和可能的错误。
+func LoadTemplates(dir string) (*Library, error) {
+	lib := &Library{
+		dir:     dir,
+		prompts: make(map[string]*Prompt),
+	}
+	if err := lib.load(); err != nil {
+		return nil, err
+	}
+	return lib, nil
+}
+
+// load 读取目录中的提示词文件
+func (l *Library) load() error {
+	if !file.IsExist(l.dir) {
+		return nil
+	}
+
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return fmt.Errorf("读取提示词目录失败 %s: %w", l.dir, err)
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".md" && ext != ".txt") {
+			continue
+		}
+		p, err := ParseFile(filepath.Join(l.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if p.Name == "" {
+			p.Name = strings.TrimSuffix(entry.Name(), ext)
+		}
+		l.prompts[p.Name] = p
+	}
+	return nil
+}
+
+// Render 使用用户变量渲染提示词正文，未提供的变量使用默认值，不修改传入的 vars
+func (p *Prompt) Render(vars map[string]string) (string, error) {
+	data := make(map[string]string, len(vars)+len(p.Vars))
+	for k, v := range vars {
+		data[k] = v
+	}
+	for _, v := range p.Vars {
+		if _, ok := data[v.Name]; !ok {
+			data[v.Name] = v.Default
+		}
+	}
+	return global.RenderPrompt(p.Content, data)
+}
+
+// ParseFile 解析单个提示词文件
+func ParseFile(path string) (*Prompt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取提示词文件失败 %s: %w", path, err)
+	}
+
+	p := &Prompt{Path: path}
+	front, body, err := SplitFrontMatter(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析提示词文件失败 %s: %w", path, err)
+	}
+	if len(front) > 0 {
+		if err := yaml.Unmarshal(front, p); err != nil {
+			return nil, fmt.Errorf("解析提示词 front-matter 失败 %s: %w", path, err)
+		}
+	}
+	p.Content = strings.TrimSpace(string(body))
+	return p, nil
+}
+
+// SplitFrontMatter 拆分以 --- 包围的 YAML front-matter 与正文，没有 front-matter 时 front 为空。
+// param data 为文件内容。
+//
+// return front-matter 内容、正文内容和可能的错误。front-matter 未闭合时返回错误。
+func SplitFrontMatter(data []byte) ([]byte, []byte, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	normalized := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(normalized, []byte("---\n")) {
+		return nil, data, nil
+	}
+
+	rest := normalized[4:]
+	if bytes.HasPrefix(rest, []byte("---\n")) {
+		return nil, rest[4:], nil
+	}
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		if bytes.HasSuffix(rest, []byte("\n---")) {
+			return rest[:len(rest)-4], nil, nil
+		}
+		return nil, nil, fmt.Errorf("front-matter 缺少结束标记 ---")
+	}
+	return rest[:end], rest[end+5:], nil
+}
+
+// Get 按名称获取提示词
+func (l *Library) Get(name string) (*Prompt, bool) {
+	p, ok := l.prompts[name]
+	return p, ok
+}
+
+// List 返回按名称排序的所有提示词
+func (l *Library) List() []*Prompt {
+	list := make([]*Prompt, 0, len(l.prompts))
+	for _, p := range l.prompts {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Dir 返回提示词目录
+func (l *Library) Dir() string {
+	return l.dir
+}
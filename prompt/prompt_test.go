@@ -0,0 +1,76 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLibrary(t *testing.T) {
+	dir := t.TempDir()
+	review := "---\nname: reviewer\ndescription: 代码审查\nmodel: gpt-4o\ntemperature: 0.2\n---\n你是一名严格的代码审查者。\n"
+	if err := os.WriteFile(filepath.Join(dir, "review.md"), []byte(review), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "translate.txt"), []byte("把用户输入翻译成英文。"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lib, err := LoadLibrary(dir)
+	if err != nil {
+		t.Fatalf("LoadLibrary() error = %v", err)
+	}
+	if n := len(lib.List()); n != 3 {
+		t.Errorf("List() has %d prompts, want 3", n)
+	}
+
+	p, ok := lib.Get("reviewer")
+	if !ok {
+		t.Fatalf("Get(reviewer) not found")
+	}
+	if p.Model != "gpt-4o" || p.GetTemperature() != 0.2 || p.Content != "你是一名严格的代码审查者。" {
+		t.Errorf("reviewer = %+v", p)
+	}
+
+	p, ok = lib.Get("translate")
+	if !ok || p.GetTemperature() != DefaultTemperature || p.Content != "把用户输入翻译成英文。" {
+		t.Errorf("translate = %+v", p)
+	}
+}
+
+func TestSplitFrontMatterUnclosed(t *testing.T) {
+	if _, _, err := SplitFrontMatter([]byte("---\nname: x\n正文")); err == nil {
+		t.Errorf("SplitFrontMatter() should fail on unclosed front-matter")
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("备注内容"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SPARROW_TEST_LANG", "Go")
+
+	p := &Prompt{
+		Name:    "review",
+		Vars:    []Variable{{Name: "focus", Default: "性能"}, {Name: "lang"}},
+		Content: `审查 {{env "SPARROW_TEST_LANG"}} 代码，关注{{.focus}}。{{file "` + path + `"}} {{.Args}}`,
+	}
+	vars := map[string]string{"Args": "main.go"}
+	got, err := p.Render(vars)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "审查 Go 代码，关注性能。备注内容 main.go"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if len(vars) != 1 {
+		t.Errorf("Render() modified the caller's vars: %v", vars)
+	}
+
+	p.Content = "{{.missing}}"
+	if _, err := p.Render(nil); err == nil {
+		t.Errorf("Render() should fail on undeclared variables")
+	}
+}
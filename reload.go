@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sparrow-cli/config"
+	"sparrow-cli/env"
+	"sparrow-cli/global"
+	"sparrow-cli/logger"
+	"sparrow-cli/watch"
+	"sync"
+	"sync/atomic"
+)
+
+// reloader 在后台监视配置文件与提示词目录，发现变化时只重新解析并校验配置。
+// 加载配置需要解析密钥引用，可能提示输入主密码或运行 cmd: 命令，与行编辑器争用终端，
+// 因此由会话在处理下一条输入前加载并应用，所有提示也在那时输出。
+type reloader struct {
+	mu             sync.Mutex
+	notices        []string    // 等待显示给用户的提示
+	configChanged  atomic.Bool // 配置文件是否发生变化且通过校验
+	promptsChanged atomic.Bool // 提示词库或模板目录是否发生变化
+}
+
+// promptDir 返回提示词库目录
+func promptDir() string {
+	return filepath.Join(env.SparrowCliHome, "prompts")
+}
+
+// templateDir 返回提示词模板目录
+func templateDir() string {
+	return filepath.Join(env.SparrowCliHome, "templates")
+}
+
+// watchConfig 开始监视配置文件与提示词目录，直到 ctx 结束
+func watchConfig(ctx context.Context) *reloader {
+	r := &reloader{}
+
+	targets := append([]string{}, config.Current().Sources...)
+	if cwd, err := os.Getwd(); err == nil {
+		// 当前目录的项目配置可能在运行中才被创建
+		targets = append(targets, filepath.Join(cwd, config.ProjectConfigFileName))
+	}
+	prompts, _ := filepath.Abs(promptDir())
+	templates, _ := filepath.Abs(templateDir())
+	targets = append(targets, prompts, templates)
+
+	go watch.New(targets...).Run(ctx, func(changed []string) {
+		configChanged := false
+		for _, path := range changed {
+			if path == prompts || path == templates {
+				r.promptsChanged.Store(true)
+			} else {
+				configChanged = true
+			}
+		}
+		if !configChanged {
+			return
+		}
+
+		// 只校验，不解析密钥；校验失败时丢弃之前的变化，继续使用原配置
+		if problems := config.Validate(config.Paths()); len(problems) > 0 {
+			err := &config.ValidationError{Problems: problems}
+			r.configChanged.Store(false)
+			logger.Warn("重新加载配置失败: %v", err)
+			r.notify(fmt.Sprintf("✗ 重新加载配置失败，继续使用原配置: %v", err))
+			return
+		}
+		r.configChanged.Store(true)
+	})
+	return r
+}
+
+// notify 记录一条提示，在处理下一条输入前显示
+func (r *reloader) notify(msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notices = append(r.notices, msg)
+}
+
+// takeNotices 取出所有待显示的提示
+func (r *reloader) takeNotices() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notices := r.notices
+	r.notices = nil
+	return notices
+}
+
+// applyReload 加载后台发现变化的配置：按名称刷新当前模型的地址与密钥，并重新加载提示词库。
+// 在两轮对话之间调用，解析密钥时可以正常提示输入。对话历史保持不变。
+func (s *session) applyReload() {
+	for _, msg := range s.reloader.takeNotices() {
+		fmt.Println(msg)
+	}
+
+	if s.reloader.configChanged.Swap(false) {
+		if c, err := config.Reload(); err != nil {
+			logger.Warn("重新加载配置失败: %v", err)
+			fmt.Printf("✗ 重新加载配置失败，继续使用原配置: %v\n", err)
+		} else {
+			logger.SetLevel(c.Logger.Level)
+			logger.Info("配置已重新加载")
+		}
+	}
+
+	if c := config.Current(); c != s.config {
+		previous := s.config
+		s.config = c
+		name := currentModelName()
+		err := config.UseModel(name)
+		switch {
+		case err != nil:
+			if m, ok := c.DefaultModel(); ok {
+				_ = config.UseModel(m.Model)
+				fmt.Printf("模型 %s 已不在配置中，切换到 %s\n", name, m.Model)
+			}
+		case c.ProjectEndpoint(name) && !previous.ProjectEndpoint(name):
+			// 项目配置在运行中改写了当前模型的地址，不能在用户不知情时把后续提问发往新地址
+			if m, ok := c.DefaultModel(); ok {
+				_ = config.UseModel(m.Model)
+				fmt.Printf("警告: 项目配置修改了模型 %s 的地址，已切换到 %s\n", name, m.Model)
+			} else {
+				global.SetCurrentModel(nil)
+				fmt.Printf("警告: 项目配置修改了模型 %s 的地址，已停止使用该模型\n", name)
+			}
+		}
+		// 配置档案按名称重新解析，使修改后的工具权限立即生效
+		if s.profile != nil {
+			if profile, err := c.Profile(s.profile.Name); err == nil {
+				s.profile = &profile
+			} else {
+				fmt.Printf("警告: %v，继续使用原配置档案\n", err)
+			}
+		}
+		fmt.Println("✓ 配置已重新加载")
+	}
+
+	if s.reloader.promptsChanged.Swap(false) {
+		s.library = initPromptLibrary()
+		s.templates = initTemplates()
+		fmt.Println("✓ 提示词库已重新加载")
+	}
+}
@@ -0,0 +1,232 @@
+// Package schema 读取 JSON Schema 并在本地校验模型输出的 JSON。
+// 支持常用的校验关键字：type、enum、const、properties、required、additionalProperties、items、
+// 长度与数值范围、pattern、format、allOf、anyOf、oneOf、not 以及指向文档内部的 $ref。
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema 解析后的 JSON Schema
+type Schema struct {
+	raw      json.RawMessage           // 紧凑格式的 Schema，用于发送给模型
+	root     any                       // 解析后的 Schema
+	patterns map[string]*regexp.Regexp // 预先编译的 pattern
+}
+
+// Load 读取 Schema 文件，.yaml 与 .yml 文件按 YAML 解析，其余按 JSON 解析
+// param path 为 Schema 文件路径。
+//
+// return Schema 和可能的错误。
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 Schema 文件失败: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("解析 Schema 文件失败 %s: %w", path, err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("Schema 文件 %s 无法转换为 JSON: %w", path, err)
+		}
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("Schema 文件 %s 有误: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse 解析 JSON 格式的 Schema，并检查其中的 pattern 与 $ref
+// param data 为 Schema 内容。
+//
+// return Schema 和可能的错误。
+func Parse(data []byte) (*Schema, error) {
+	root, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的 JSON: %w", err)
+	}
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, fmt.Errorf("Schema 必须是对象或布尔值")
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, err
+	}
+	s := &Schema{raw: compact.Bytes(), root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.check(root, "$"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Raw 返回紧凑格式的 Schema
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// maxNameLength 服务商允许的 Schema 名称最大长度
+const maxNameLength = 64
+
+// Name 返回 Schema 的名称，取自 title，只保留字母、数字、下划线与连字符，没有 title 时返回 response
+func (s *Schema) Name() string {
+	obj, _ := s.root.(map[string]any)
+	title, _ := obj["title"].(string)
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		case r == ' ':
+			return '_'
+		default:
+			return -1
+		}
+	}, title)
+	if name == "" {
+		return "response"
+	}
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return name
+}
+
+// RootType 返回 Schema 顶层的 type，未声明 type 但声明了 properties 时视为 object
+func (s *Schema) RootType() string {
+	obj, _ := s.root.(map[string]any)
+	if t, ok := obj["type"].(string); ok {
+		return t
+	}
+	if _, ok := obj["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// check 递归检查 Schema：编译 pattern，确认 $ref 指向文档内部、能找到且没有循环
+func (s *Schema) check(node any, path string) error {
+	sc, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if value, ok := sc["pattern"]; ok {
+		pattern, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s.pattern 必须是字符串", path)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s.pattern 不是有效的正则表达式: %w", path, err)
+		}
+		s.patterns[pattern] = re
+	}
+	if _, ok := sc["$ref"]; ok {
+		if err := s.checkRef(sc); err != nil {
+			return fmt.Errorf("%s.$ref %w", path, err)
+		}
+	}
+
+	// 只检查值为 Schema 的关键字，properties 等映射的键是字段名而不是关键字
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if err := s.check(sc[key], path+"."+key); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := sc[key].([]any)
+		for i, item := range list {
+			if err := s.check(item, fmt.Sprintf("%s.%s[%d]", path, key, i)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range []string{"properties", "$defs", "definitions"} {
+		children, _ := sc[key].(map[string]any)
+		for name, child := range children {
+			if err := s.check(child, path+"."+key+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRef 沿 $ref 查找，引用不存在或只由引用组成循环时返回错误
+func (s *Schema) checkRef(sc map[string]any) error {
+	visited := make(map[uintptr]bool)
+	for {
+		ref, ok := sc["$ref"]
+		if !ok {
+			return nil
+		}
+		refString, ok := ref.(string)
+		if !ok {
+			return fmt.Errorf("必须是字符串")
+		}
+		id := reflect.ValueOf(sc).Pointer()
+		if visited[id] {
+			return fmt.Errorf("%s 构成循环引用", refString)
+		}
+		visited[id] = true
+
+		target, err := s.resolve(refString)
+		if err != nil {
+			return err
+		}
+		if sc, ok = target.(map[string]any); !ok {
+			return nil
+		}
+	}
+}
+
+// resolve 按 JSON Pointer 查找文档内部的 Schema，例如 #/$defs/address
+func (s *Schema) resolve(ref string) (any, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("只支持指向文档内部的引用: %s", ref)
+	}
+	node := s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("引用的位置不存在: %s", ref)
+		}
+		if node, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("引用的位置不存在: %s", ref)
+		}
+	}
+	return node, nil
+}
+
+// decode 解析 JSON，数字保留为 json.Number 以免大整数丢失精度，且只允许一个 JSON 值
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if err := dec.Decode(new(any)); err != io.EOF {
+		return nil, fmt.Errorf("JSON 值之后包含多余的内容")
+	}
+	return v, nil
+}
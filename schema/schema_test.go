@@ -0,0 +1,192 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const invoiceSchema = `{
+  "title": "Invoice Info",
+  "type": "object",
+  "required": ["number", "date", "items"],
+  "additionalProperties": false,
+  "properties": {
+    "number": {"type": "string", "pattern": "^INV-[0-9]+$"},
+    "date": {"type": "string", "format": "date"},
+    "currency": {"enum": ["CNY", "USD"]},
+    "email": {"type": ["string", "null"], "format": "email"},
+    "items": {
+      "type": "array",
+      "minItems": 1,
+      "items": {"$ref": "#/$defs/item"}
+    }
+  },
+  "$defs": {
+    "item": {
+      "type": "object",
+      "required": ["name", "quantity", "price"],
+      "properties": {
+        "name": {"type": "string", "minLength": 1},
+        "quantity": {"type": "integer", "minimum": 1},
+        "price": {"type": "number", "exclusiveMinimum": 0}
+      }
+    }
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(invoiceSchema))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.Name() != "Invoice_Info" {
+		t.Errorf("Name() = %q", s.Name())
+	}
+	if strings.Contains(string(s.Raw()), "\n") {
+		t.Errorf("Raw() should be compact: %s", s.Raw())
+	}
+
+	tests := []struct {
+		name     string
+		json     string
+		problems []string
+	}{
+		{
+			name: "valid",
+			json: `{"number":"INV-001","date":"2026-10-18","currency":"CNY","email":null,"items":[{"name":"键盘","quantity":2,"price":199.5}]}`,
+		},
+		{
+			name: "integer written as float",
+			json: `{"number":"INV-002","date":"2026-10-18","items":[{"name":"鼠标","quantity":1.0,"price":1}]}`,
+		},
+		{
+			name: "invalid",
+			json: `{"number":"001","date":"18/10/2026","currency":"EUR","email":"nobody","items":[{"name":"","quantity":1.5,"price":0},{"quantity":"2"}],"note":"x"}`,
+			problems: []string{
+				`$.currency: 应为以下值之一: "CNY", "USD"`,
+				"$.date: 不是有效的 date 格式",
+				"$.email: 不是有效的 email 格式",
+				"$.items[0].name: 长度不能少于 1 个字符",
+				"$.items[0].price: 应大于 0",
+				"$.items[0].quantity: 应为 integer 类型，实际为 number",
+				"$.items[1]: 缺少必填字段 name",
+				"$.items[1]: 缺少必填字段 price",
+				"$.items[1].quantity: 应为 integer 类型，实际为 string",
+				"$: 不允许出现字段 note",
+				"$.number: 应匹配正则表达式 ^INV-[0-9]+$",
+			},
+		},
+		{
+			name:     "wrong root type",
+			json:     `[1, 2]`,
+			problems: []string{"$: 应为 object 类型，实际为 array"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Validate([]byte(tt.json))
+			var validationErr *ValidationError
+			if tt.problems == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.problems) {
+				t.Errorf("Problems =\n%s\nwant\n%s", strings.Join(validationErr.Problems, "\n"), strings.Join(tt.problems, "\n"))
+			}
+		})
+	}
+
+	if _, err := s.Validate([]byte(`{"number": "INV-1"`)); err == nil || errors.As(err, new(*ValidationError)) {
+		t.Errorf("Validate() of broken JSON error = %v, want parse error", err)
+	}
+	if _, err := s.Validate([]byte(`{} {}`)); err == nil {
+		t.Errorf("Validate() should reject trailing content")
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"type": "object",
+		"properties": {
+			"pattern": {"type": "string"},
+			"id": {"oneOf": [{"type": "integer"}, {"type": "string", "format": "uri"}]},
+			"tags": {"type": "array", "uniqueItems": true, "maxItems": 3, "items": {"not": {"const": "spam"}}},
+			"score": {"anyOf": [{"type": "number", "multipleOf": 0.5}, {"type": "null"}]}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := s.Validate([]byte(`{"pattern":"x","id":"https://example.com/1","tags":["a","b"],"score":2.5}`)); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	_, err = s.Validate([]byte(`{"id":true,"tags":["a","a","spam","b"],"score":0.3}`))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	want := []string{
+		"$.id: 应恰好符合 oneOf 中的一项，实际符合 0 项",
+		"$.score: 不符合 anyOf 中的任何一项",
+		"$.tags: 最多只能有 3 个元素",
+		"$.tags: 第 0 个与第 1 个元素重复",
+		"$.tags[2]: 不应符合 not 中的 Schema",
+	}
+	if !reflect.DeepEqual(validationErr.Problems, want) {
+		t.Errorf("Problems =\n%s\nwant\n%s", strings.Join(validationErr.Problems, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": "object"`,
+		`[]`,
+		`{"properties": {"name": {"pattern": "["}}}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+		`{"$ref": "#"}`,
+		`{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`,
+	} {
+		if _, err := Parse([]byte(schema)); err == nil {
+			t.Errorf("Parse(%s) should fail", schema)
+		}
+	}
+
+	// 递归结构中的引用不是循环
+	if _, err := Parse([]byte(`{"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#"}}}}`)); err != nil {
+		t.Errorf("Parse() of recursive schema error = %v", err)
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "person.yaml")
+	content := `type: object
+required: [name, age]
+properties:
+  name: {type: string}
+  age: {type: integer, maximum: 150}
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := s.Validate([]byte(`{"name":"张三","age":30}`)); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if _, err := s.Validate([]byte(`{"name":"张三","age":200}`)); err == nil {
+		t.Errorf("Validate() should reject age above maximum")
+	}
+}
@@ -0,0 +1,405 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxProblems 最多报告的问题数，避免重新提问时的提示过长
+const maxProblems = 20
+
+// ValidationError JSON 不符合 Schema
+type ValidationError struct {
+	Problems []string // 每个问题的位置与原因，例如 $.items[0].price: 应为 number 类型
+}
+
+func (e *ValidationError) Error() string {
+	return "JSON 不符合 Schema: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate 解析并校验 JSON
+// param data 为待校验的 JSON。
+//
+// return 解析后的值；不是有效的 JSON 时返回解析错误，不符合 Schema 时返回 *ValidationError。
+func (s *Schema) Validate(data []byte) (any, error) {
+	v, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的 JSON: %w", err)
+	}
+	var problems []string
+	s.validate(s.root, v, "$", &problems)
+	if len(problems) > 0 {
+		if len(problems) > maxProblems {
+			problems = append(problems[:maxProblems], fmt.Sprintf("另有 %d 个问题未列出", len(problems)-maxProblems))
+		}
+		return v, &ValidationError{Problems: problems}
+	}
+	return v, nil
+}
+
+// validate 按 Schema 校验值，问题追加到 problems
+func (s *Schema) validate(schema, v any, path string, problems *[]string) {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch sc := schema.(type) {
+	case bool:
+		if !sc {
+			report("不允许出现该值")
+		}
+		return
+	case map[string]any:
+		if ref, ok := sc["$ref"].(string); ok {
+			if target, err := s.resolve(ref); err == nil {
+				s.validate(target, v, path, problems)
+			}
+		}
+
+		if !s.validateType(sc["type"], v) {
+			report("应为 %s 类型，实际为 %s", typeNames(sc["type"]), typeOf(v))
+			// 类型不符时其余关键字的结果没有意义
+			return
+		}
+		if enum, ok := sc["enum"].([]any); ok && !containsValue(enum, v) {
+			report("应为以下值之一: %s", formatValues(enum))
+		}
+		if c, ok := sc["const"]; ok && !equal(c, v) {
+			report("应为 %s", formatValues([]any{c}))
+		}
+
+		switch value := v.(type) {
+		case string:
+			s.validateString(sc, value, report)
+		case json.Number:
+			validateNumber(sc, value, report)
+		case map[string]any:
+			s.validateObject(sc, value, path, problems, report)
+		case []any:
+			s.validateArray(sc, value, path, problems, report)
+		}
+		s.validateCombinators(sc, v, path, problems, report)
+	}
+}
+
+// validateType 检查 type 关键字，type 可以是字符串或字符串列表
+func (s *Schema) validateType(t, v any) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, v)
+	case []any:
+		for _, name := range t {
+			if name, ok := name.(string); ok && isType(name, v) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// isType 判断值是否为 JSON Schema 中的指定类型
+func isType(name string, v any) bool {
+	switch name {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	default:
+		return typeOf(v) == name
+	}
+}
+
+// typeOf 返回值的 JSON 类型名称
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// typeNames 格式化 type 关键字
+func typeNames(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " 或 ")
+	}
+	return fmt.Sprint(t)
+}
+
+// validateString 校验字符串的长度、pattern 与 format
+func (s *Schema) validateString(sc map[string]any, v string, report func(string, ...any)) {
+	length := utf8.RuneCountInString(v)
+	if n, ok := intKeyword(sc, "minLength"); ok && length < n {
+		report("长度不能少于 %d 个字符", n)
+	}
+	if n, ok := intKeyword(sc, "maxLength"); ok && length > n {
+		report("长度不能超过 %d 个字符", n)
+	}
+	if re := s.patterns[stringKeyword(sc, "pattern")]; re != nil && !re.MatchString(v) {
+		report("应匹配正则表达式 %s", re)
+	}
+	if format, ok := sc["format"].(string); ok && !validFormat(format, v) {
+		report("不是有效的 %s 格式", format)
+	}
+}
+
+// validFormat 校验常用的 format，未知的 format 视为有效
+func validFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", v)
+		if err != nil {
+			_, err = time.Parse(time.TimeOnly, v)
+		}
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(v)
+		return err == nil && addr.Address == v
+	case "uri", "url":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+	default:
+		return true
+	}
+}
+
+// validateNumber 校验数值范围与倍数
+func validateNumber(sc map[string]any, v json.Number, report func(string, ...any)) {
+	f, err := v.Float64()
+	if err != nil {
+		report("不是有效的数值")
+		return
+	}
+	if min, ok := numberKeyword(sc, "minimum"); ok && f < min {
+		report("不能小于 %v", min)
+	}
+	if max, ok := numberKeyword(sc, "maximum"); ok && f > max {
+		report("不能大于 %v", max)
+	}
+	if min, ok := numberKeyword(sc, "exclusiveMinimum"); ok && f <= min {
+		report("应大于 %v", min)
+	}
+	if max, ok := numberKeyword(sc, "exclusiveMaximum"); ok && f >= max {
+		report("应小于 %v", max)
+	}
+	if m, ok := numberKeyword(sc, "multipleOf"); ok && m > 0 {
+		if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			report("应为 %v 的倍数", m)
+		}
+	}
+}
+
+// validateObject 校验对象的必填字段、属性与额外属性
+func (s *Schema) validateObject(sc map[string]any, v map[string]any, path string, problems *[]string, report func(string, ...any)) {
+	if required, ok := sc["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, exists := v[name]; !exists {
+					report("缺少必填字段 %s", name)
+				}
+			}
+		}
+	}
+	if n, ok := intKeyword(sc, "minProperties"); ok && len(v) < n {
+		report("至少应有 %d 个字段", n)
+	}
+	if n, ok := intKeyword(sc, "maxProperties"); ok && len(v) > n {
+		report("最多只能有 %d 个字段", n)
+	}
+
+	properties, _ := sc["properties"].(map[string]any)
+	additional, hasAdditional := sc["additionalProperties"]
+	// 按字段名排序，使问题的顺序稳定
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		childPath := path + "." + name
+		if property, ok := properties[name]; ok {
+			s.validate(property, v[name], childPath, problems)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			report("不允许出现字段 %s", name)
+			continue
+		}
+		s.validate(additional, v[name], childPath, problems)
+	}
+}
+
+// validateArray 校验数组的长度、元素与唯一性
+func (s *Schema) validateArray(sc map[string]any, v []any, path string, problems *[]string, report func(string, ...any)) {
+	if n, ok := intKeyword(sc, "minItems"); ok && len(v) < n {
+		report("至少应有 %d 个元素", n)
+	}
+	if n, ok := intKeyword(sc, "maxItems"); ok && len(v) > n {
+		report("最多只能有 %d 个元素", n)
+	}
+	if unique, _ := sc["uniqueItems"].(bool); unique {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if equal(v[i], v[j]) {
+					report("第 %d 个与第 %d 个元素重复", i, j)
+				}
+			}
+		}
+	}
+	if items, ok := sc["items"]; ok {
+		for i, item := range v {
+			s.validate(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+// validateCombinators 校验 allOf、anyOf、oneOf 与 not
+func (s *Schema) validateCombinators(sc map[string]any, v any, path string, problems *[]string, report func(string, ...any)) {
+	if all, ok := sc["allOf"].([]any); ok {
+		for _, sub := range all {
+			s.validate(sub, v, path, problems)
+		}
+	}
+	if anyOf, ok := sc["anyOf"].([]any); ok && s.countMatches(anyOf, v) == 0 {
+		report("不符合 anyOf 中的任何一项")
+	}
+	if oneOf, ok := sc["oneOf"].([]any); ok {
+		if n := s.countMatches(oneOf, v); n != 1 {
+			report("应恰好符合 oneOf 中的一项，实际符合 %d 项", n)
+		}
+	}
+	if not, ok := sc["not"]; ok && s.matches(not, v) {
+		report("不应符合 not 中的 Schema")
+	}
+}
+
+// countMatches 返回值符合的 Schema 个数
+func (s *Schema) countMatches(schemas []any, v any) int {
+	n := 0
+	for _, sub := range schemas {
+		if s.matches(sub, v) {
+			n++
+		}
+	}
+	return n
+}
+
+// matches 判断值是否符合 Schema
+func (s *Schema) matches(schema, v any) bool {
+	var problems []string
+	s.validate(schema, v, "$", &problems)
+	return len(problems) == 0
+}
+
+// stringKeyword 读取字符串关键字
+func stringKeyword(sc map[string]any, key string) string {
+	value, _ := sc[key].(string)
+	return value
+}
+
+// intKeyword 读取非负整数关键字
+func intKeyword(sc map[string]any, key string) (int, bool) {
+	f, ok := numberKeyword(sc, key)
+	return int(f), ok
+}
+
+// numberKeyword 读取数值关键字
+func numberKeyword(sc map[string]any, key string) (float64, bool) {
+	n, ok := sc[key].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// containsValue 判断列表中是否包含与 v 相等的值
+func containsValue(list []any, v any) bool {
+	for _, item := range list {
+		if equal(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal 比较两个 JSON 值，数值按大小比较，1 与 1.0 相等
+func equal(a, b any) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// normalize 将数值统一转换为 float64
+func normalize(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalize(item)
+		}
+		return items
+	case map[string]any:
+		obj := make(map[string]any, len(v))
+		for key, value := range v {
+			obj[key] = normalize(value)
+		}
+		return obj
+	default:
+		return v
+	}
+}
+
+// formatValues 将值列表格式化为 JSON
+func formatValues(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
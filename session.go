@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sparrow-cli/attach"
+	"sparrow-cli/client"
+	"sparrow-cli/config"
+	"sparrow-cli/global"
+	"sparrow-cli/logger"
+	"sparrow-cli/markdown"
+	"sparrow-cli/prompt"
+	"sparrow-cli/terminal"
+	"strings"
+)
+
+// session 一次交互式对话的状态
+type session struct {
+	messages    []client.Message      // 对话历史
+	editor      *terminal.Editor      // 行编辑器
+	renderer    *markdown.Renderer    // 回答渲染器
+	library     *prompt.Library       // 提示词库
+	templates   *prompt.Library       // 用户提示词模板
+	sysPrompt   *prompt.Prompt        // 当前使用的系统提示词
+	temperature float64               // 当前使用的温度
+	lastAnswer  string                // 最近一次回答的完整内容
+	profile     *config.ProfileConfig // 当前使用的配置档案，未使用时为 nil
+	config      *config.Config        // 会话当前应用的配置
+	reloader    *reloader             // 配置热加载
+}
+
+func run(library, templates *prompt.Library, sysPrompt *prompt.Prompt, profile *config.ProfileConfig) {
+	s := &session{
+		library:   library,
+		templates: templates,
+		// 创建行编辑器
+		editor: initEditor(),
+		// 标准输出不是终端时自动关闭 Markdown 渲染
+		renderer: markdown.NewRenderer(os.Stdout, !*rawOutput && terminal.SupportsColor()),
+	}
+	s.renderer.SetWidth(func() int { return terminal.Width(os.Stdout) })
+	s.usePrompt(sysPrompt)
+	if profile != nil {
+		s.applyProfile(profile)
+	}
+
+	// 监视配置文件与提示词目录，修改后无需重启即可生效
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.config = config.Current()
+	s.reloader = watchConfig(ctx)
+
+	for {
+		// 用户输入的问题
+		line, err := s.editor.ReadLine("请输入问题：")
+		if errors.Is(err, terminal.ErrInterrupt) {
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Error("读取输入失败: %v", err)
+			}
+			break
+		}
+		msg := strings.TrimSpace(line)
+		if msg == "" {
+			continue
+		}
+		s.applyReload()
+		if msg == "!quit" {
+			break
+		}
+		if strings.HasPrefix(msg, "/") {
+			s.handleCommand(msg)
+			continue
+		}
+
+		s.ask(msg)
+	}
+}
+
+// usePrompt 切换系统提示词，替换对话中的系统消息并应用提示词的默认模型与参数
+func (s *session) usePrompt(p *prompt.Prompt) {
+	content, err := p.Render(s.askVars(p))
+	if err != nil {
+		fmt.Printf("警告: %v，使用未渲染的系统提示词\n", err)
+		content = p.Content
+	}
+	global.SetSystemPrompt(content)
+	sys := client.Message{
+		Role:    client.SysRole,
+		Content: global.GetSystemPrompt(),
+	}
+	if len(s.messages) > 0 && s.messages[0].Role == client.SysRole {
+		s.messages[0] = sys
+	} else {
+		s.messages = append([]client.Message{sys}, s.messages...)
+	}
+
+	s.sysPrompt = p
+	s.temperature = p.GetTemperature()
+	if p.Model != "" {
+		if err := config.UseModel(p.Model); err != nil {
+			fmt.Printf("警告: %v，继续使用当前模型\n", err)
+		}
+	}
+}
+
+// askVars 逐个询问提示词中声明的变量，直接回车时使用默认值
+func (s *session) askVars(p *prompt.Prompt) map[string]string {
+	vars := make(map[string]string, len(p.Vars))
+	for _, v := range p.Vars {
+		question := v.Prompt
+		if question == "" {
+			question = v.Name
+		}
+		if v.Default != "" {
+			question += "（默认: " + v.Default + "）"
+		}
+
+		answer, err := s.editor.Prompt(question + "：")
+		answer = strings.TrimSpace(answer)
+		if err != nil || answer == "" {
+			answer = v.Default
+		}
+		vars[v.Name] = answer
+	}
+	return vars
+}
+
+// ask 发送用户问题并输出回答
+func (s *session) ask(msg string) {
+	message, turn, ok := s.userMessage(msg)
+	if !ok {
+		return
+	}
+	s.messages = append(s.messages, message)
+
+	// 按配置档案的路由规则与备用模型确定本轮依次尝试的模型
+	candidates := s.profile.Candidates(currentModelName(), turn)
+	responseBody, model, err := s.streamAnswer(candidates, message.HasImages())
+	if err != nil {
+		// 本轮提问没有得到回答，从对话历史中移除，以便修改后重新提问
+		s.messages = s.messages[:len(s.messages)-1]
+		fmt.Printf("✗ %v\n", err)
+		return
+	}
+	if err := s.renderer.Flush(); err != nil {
+		logger.Warn("输出回答失败: %v", err)
+	}
+
+	// 打印响应结果
+	if responseBody.Filtered() {
+		fmt.Println("⚠ 回答触发了内容过滤，已被截断")
+	}
+	fmt.Printf("模型: %s\n", model)
+	fmt.Printf("Token使用: 输入=%d, 输出=%d, 总计=%d\n",
+		responseBody.Usage.PromptTokens,
+		responseBody.Usage.CompletionTokens,
+		responseBody.Usage.TotalTokens)
+	if m, err := s.config.Model(model); err == nil {
+		if cost, ok := m.Cost(responseBody.Usage.PromptTokens, responseBody.Usage.CompletionTokens); ok {
+			fmt.Printf("费用: %s%.6f\n", m.Currency, cost)
+		}
+	}
+
+	// 将AI的回复添加到对话历史中，并记录实际回答的模型
+	if len(responseBody.Choices) > 0 {
+		s.lastAnswer = responseBody.Choices[0].Message.Content
+		s.messages = append(s.messages, client.Message{
+			Role:    client.AssistantRole,
+			Content: s.lastAnswer,
+			Model:   model,
+		})
+	}
+}
+
+// userMessage 展开问题中的 @ 文件引用，构造用户消息
+//
+// return 用户消息、用于路由的提问特征，以及是否成功。失败原因已输出。
+func (s *session) userMessage(msg string) (client.Message, config.Turn, bool) {
+	attachConf := config.Current().Attach
+	expanded, err := attach.Expand(msg, attach.Options{
+		MaxFileBytes:  attachConf.MaxFileBytes,
+		MaxTotalBytes: attachConf.MaxTotalBytes,
+		MaxFiles:      attachConf.MaxFiles,
+		MaxImageBytes: attachConf.MaxImageBytes,
+		Ignore:        attachConf.Ignore,
+	})
+	if err != nil {
+		fmt.Printf("展开文件引用失败: %v\n", err)
+		return client.Message{}, config.Turn{}, false
+	}
+	for _, skipped := range expanded.Skipped {
+		fmt.Printf("已跳过: %s\n", skipped)
+	}
+	if len(expanded.Files) > 0 || len(expanded.Images) > 0 {
+		fmt.Printf("已附加 %d 个文件、%d 张图片，共 %d 字节\n", len(expanded.Files), len(expanded.Images), expanded.TotalBytes)
+	}
+
+	message := client.Message{
+		Role:    client.UserRole,
+		Content: expanded.Content,
+	}
+	if len(expanded.Images) > 0 {
+		message.Parts = append(message.Parts, client.TextPart(expanded.Content))
+		for _, image := range expanded.Images {
+			message.Parts = append(message.Parts, client.ImagePart(image.MIME, image.Data))
+		}
+	}
+
+	turn := config.Turn{
+		Text:        expanded.Content,
+		Attachments: len(expanded.Files) + len(expanded.Images),
+		Images:      len(expanded.Images),
+	}
+	return message, turn, true
+}
+
+// streamAnswer 依次尝试候选模型，直到某个模型开始输出回答。网络错误、超时、被限流或服务端出错时尝试下一个模型；
+// 请求有误、认证失败或提问被内容过滤拦截时直接报告，换一个模型通常同样失败，也可能把被拦截的内容发给其他服务商；
+// 回答开始输出后出错不再切换，以免重复输出。地址由项目配置设置的模型只有作为当前模型时才会使用，
+// 不会被路由规则或备用模型自动选中。每个请求显式指定模型，不会修改当前模型。
+// param candidates 为按尝试顺序排列的模型名称。
+// param hasImages 为本轮提问是否包含图片，不支持图片的模型会被跳过；之前轮次中的图片发送给这类模型时会被省略。
+//
+// return 响应内容、实际回答的模型和可能的错误。
+func (s *session) streamAnswer(candidates []string, hasImages bool) (*client.ResponseBody, string, error) {
+	var failures []string
+	for i, name := range candidates {
+		model, err := s.config.Model(name)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		if name != currentModelName() && s.config.ProjectEndpoint(name) {
+			failures = append(failures, fmt.Sprintf("%s: 地址由项目配置设置，只有明确选择时才使用", name))
+			continue
+		}
+		if hasImages && !client.SupportsImages(model.Provider) {
+			failures = append(failures, fmt.Sprintf("%s: 服务商 %s 不支持图片输入", name, model.Provider))
+			continue
+		}
+		if i > 0 {
+			fmt.Printf("↪ 改用模型 %s\n", name)
+		}
+
+		started := false
+		output := printContent(s.renderer)
+		// 按模型的传输设置发送请求，超时、代理与证书见 models[].transport
+		c := client.New(model)
+		responseBody, err := c.ChatStream(context.Background(), s.messages, s.temperature, func(content string, isFinished bool) {
+			started = true
+			output(content, isFinished)
+		})
+		if err != nil {
+			if started {
+				return nil, name, fmt.Errorf("解析模型 %s 的响应失败: %w", name, err)
+			}
+			if !client.Retryable(err) {
+				logger.Warn("模型 %s 请求失败: %v", name, err)
+				return nil, name, fmt.Errorf("模型 %s 请求失败: %w", name, err)
+			}
+			logger.Warn("模型 %s 请求失败: %v", name, err)
+			fmt.Printf("✗ 模型 %s 请求失败: %v\n", name, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		logger.Info("本轮由模型 %s 回答", name)
+		return responseBody, name, nil
+	}
+	return nil, "", fmt.Errorf("所有模型均不可用:\n  %s", strings.Join(failures, "\n  "))
+}
+
+// currentModelName 返回当前模型名称，未配置模型时返回提示文本
+func currentModelName() string {
+	if global.CurrentModel == nil {
+		return "未配置"
+	}
+	return global.CurrentModel.Name
+}
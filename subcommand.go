@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"sparrow-cli/config"
+	"time"
+)
+
+// subcommand 命令行子命令，例如 sparrow-cli config show
+type subcommand struct {
+	name  string                    // 子命令名称
+	usage string                    // 参数说明
+	desc  string                    // 子命令描述
+	raw   bool                      // 为 true 时不预先加载配置与日志组件，由子命令自行处理
+	run   func(args []string) error // 子命令处理函数
+}
+
+// subcommands 已注册的子命令
+var subcommands = make(map[string]*subcommand)
+
+// registerSubcommand 注册子命令，各子命令文件在 init 中调用
+func registerSubcommand(cmd *subcommand) {
+	subcommands[cmd.name] = cmd
+}
+
+// usage 输出命令行帮助
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "用法: %s [选项] [子命令]\n\n选项:\n", os.Args[0])
+	flag.PrintDefaults()
+
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(out, "\n子命令:")
+	for _, name := range names {
+		cmd := subcommands[name]
+		fmt.Fprintf(out, "  %-28s %s\n", cmd.name+" "+cmd.usage, cmd.desc)
+	}
+}
+
+// runSubcommand 执行子命令并返回进程退出码
+func runSubcommand(args []string) int {
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知子命令: %s\n\n", args[0])
+		usage()
+		return 2
+	}
+
+	if !cmd.raw {
+		if err := config.LoadConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+			return 1
+		}
+		initializationCtx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+		initComponents(initializationCtx)
+		cancel()
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
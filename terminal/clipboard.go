@@ -0,0 +1,37 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxOSC52Bytes 部分终端对 OSC 52 序列长度有限制，超过该大小的内容不再尝试复制
+const maxOSC52Bytes = 100 * 1024
+
+// CopyToClipboard 通过 OSC 52 转义序列将文本复制到终端所在机器的剪贴板。
+// 该方式由终端模拟器处理，因此通过 SSH 登录远程机器时同样可用；在 tmux 与 screen 中会自动包装序列。
+// param w 为终端输出，通常为 os.Stdout。
+// param text 为要复制的文本。
+//
+// return 可能的错误。内容过大或写入失败时返回错误。
+func CopyToClipboard(w io.Writer, text string) error {
+	encoded := base64.StdEncoding.EncodeToString([]byte(text))
+	if len(encoded) > maxOSC52Bytes {
+		return fmt.Errorf("内容过大（%d 字节），无法通过 OSC 52 复制", len(text))
+	}
+
+	seq := "\x1b]52;c;" + encoded + "\a"
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	case os.Getenv("STY") != "":
+		seq = "\x1bP" + seq + "\x1b\\"
+	}
+
+	if _, err := io.WriteString(w, seq); err != nil {
+		return fmt.Errorf("写入剪贴板序列失败: %w", err)
+	}
+	return nil
+}
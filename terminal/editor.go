@@ -0,0 +1,524 @@
+package terminal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sparrow-cli/history"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupt 用户按下 Ctrl-C 时返回的错误
+var ErrInterrupt = errors.New("interrupt")
+
+// key 编辑器识别的按键
+type key int
+
+const (
+	keyRune key = iota // 普通字符
+	keyEnter
+	keyInterrupt
+	keyEOF
+	keyBackspace
+	keyDelete
+	keyTab
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyKillToEnd
+	keyKillToStart
+	keyKillWord
+	keyClear
+	keySearch
+	keyCancel
+	keyUnknown
+)
+
+// Completer 补全函数，根据光标前的单词返回补全候选项
+type Completer func(word string) []string
+
+// Editor 交互式行编辑器，支持历史记录浏览、Ctrl-R 反向搜索与 Tab 补全。
+// 标准输入不是终端时退化为按行读取。
+type Editor struct {
+	in      *os.File
+	out     *os.File
+	reader  *bufio.Reader
+	history *history.History
+
+	// Completer 可选的 Tab 补全函数
+	Completer Completer
+}
+
+// lineState 单次读取过程中的编辑状态
+type lineState struct {
+	prompt  string
+	buf     []rune
+	pos     int    // 光标在 buf 中的位置
+	histIdx int    // 当前浏览的历史下标，等于历史条数时表示正在编辑的新行
+	saved   []rune // 开始浏览历史前正在编辑的内容
+}
+
+// NewEditor 创建基于标准输入输出的行编辑器。
+// param h 为输入历史，可为 nil。
+//
+// return 创建完成的行编辑器。
+func NewEditor(h *history.History) *Editor {
+	return &Editor{
+		in:      os.Stdin,
+		out:     os.Stdout,
+		reader:  bufio.NewReader(os.Stdin),
+		history: h,
+	}
+}
+
+// ReadLine 显示提示符并读取一行输入，读取成功的非空输入会被加入历史，包括标准输入不是终端时按行读取的输入。
+// param prompt 为提示符。
+//
+// return 用户输入的内容和可能的错误。输入结束时返回 io.EOF，按下 Ctrl-C 时返回 ErrInterrupt。
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	return e.readLine(prompt, true)
+}
+
+// Prompt 显示提示符并读取一行输入，输入不会被加入历史，适用于确认、参数等临时输入。
+// param prompt 为提示符。
+//
+// return 用户输入的内容和可能的错误。
+func (e *Editor) Prompt(prompt string) (string, error) {
+	return e.readLine(prompt, false)
+}
+
+// Confirm 询问用户是否继续，仅当输入 y 或 yes 时返回 true
+func (e *Editor) Confirm(prompt string) bool {
+	answer, err := e.Prompt(prompt + " [y/N] ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// readLine 读取一行输入，record 为 true 时将输入加入历史，终端与非终端输入都会记录
+func (e *Editor) readLine(prompt string, record bool) (string, error) {
+	line, err := e.readInput(prompt)
+	if err != nil {
+		return "", err
+	}
+	if record && e.history != nil {
+		if addErr := e.history.Add(line); addErr != nil {
+			// 历史写入失败不影响本次输入
+			fmt.Fprintf(e.out, "警告: 保存输入历史失败: %v\n", addErr)
+		}
+	}
+	return line, nil
+}
+
+// readInput 终端中以原始模式逐键编辑输入，标准输入不是终端或无法进入原始模式时按行读取
+func (e *Editor) readInput(prompt string) (string, error) {
+	fd := int(e.in.Fd())
+	if !term.IsTerminal(fd) {
+		return e.readPlain(prompt)
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer func() {
+		_ = term.Restore(fd, oldState)
+	}()
+	return e.readRaw(prompt)
+}
+
+// readPlain 非终端模式下按行读取输入
+func (e *Editor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readRaw 原始模式下逐键读取并编辑输入
+func (e *Editor) readRaw(prompt string) (string, error) {
+	st := &lineState{prompt: prompt}
+	if e.history != nil {
+		st.histIdx = e.history.Len()
+	}
+	e.refresh(st)
+
+	for {
+		k, r, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch k {
+		case keyEnter:
+			e.write("\r\n")
+			return string(st.buf), nil
+		case keyInterrupt:
+			e.write("^C\r\n")
+			return "", ErrInterrupt
+		case keyEOF:
+			if len(st.buf) == 0 {
+				e.write("\r\n")
+				return "", io.EOF
+			}
+			st.deleteAt(st.pos)
+		case keyRune:
+			st.insert(r)
+		case keyBackspace:
+			if st.pos > 0 {
+				st.pos--
+				st.deleteAt(st.pos)
+			}
+		case keyDelete:
+			st.deleteAt(st.pos)
+		case keyLeft:
+			if st.pos > 0 {
+				st.pos--
+			}
+		case keyRight:
+			if st.pos < len(st.buf) {
+				st.pos++
+			}
+		case keyHome:
+			st.pos = 0
+		case keyEnd:
+			st.pos = len(st.buf)
+		case keyKillToEnd:
+			st.buf = st.buf[:st.pos]
+		case keyKillToStart:
+			st.buf = append([]rune{}, st.buf[st.pos:]...)
+			st.pos = 0
+		case keyKillWord:
+			st.killWord()
+		case keyClear:
+			e.write("\x1b[H\x1b[2J")
+		case keyTab:
+			e.complete(st)
+		case keyUp:
+			e.historyPrev(st)
+		case keyDown:
+			e.historyNext(st)
+		case keySearch:
+			submit, err := e.search(st)
+			if err != nil {
+				return "", err
+			}
+			if submit {
+				e.write("\r\n")
+				return string(st.buf), nil
+			}
+		}
+		e.refresh(st)
+	}
+}
+
+// historyPrev 切换到上一条历史
+func (e *Editor) historyPrev(st *lineState) {
+	if e.history == nil || st.histIdx == 0 {
+		return
+	}
+	if st.histIdx == e.history.Len() {
+		st.saved = append([]rune{}, st.buf...)
+	}
+	st.histIdx--
+	st.setBuf([]rune(e.history.At(st.histIdx)))
+}
+
+// historyNext 切换到下一条历史，越过最新一条时恢复正在编辑的内容
+func (e *Editor) historyNext(st *lineState) {
+	if e.history == nil || st.histIdx >= e.history.Len() {
+		return
+	}
+	st.histIdx++
+	if st.histIdx == e.history.Len() {
+		st.setBuf(st.saved)
+		return
+	}
+	st.setBuf([]rune(e.history.At(st.histIdx)))
+}
+
+// search Ctrl-R 反向增量搜索。
+// return submit 为 true 表示用户直接回车提交了匹配项。
+func (e *Editor) search(st *lineState) (bool, error) {
+	if e.history == nil {
+		return false, nil
+	}
+
+	original := append([]rune{}, st.buf...)
+	var query []rune
+	matchIdx := -1
+
+	for {
+		label := "reverse-i-search"
+		match := ""
+		if matchIdx >= 0 {
+			match = e.history.At(matchIdx)
+		} else if len(query) > 0 {
+			label = "failed reverse-i-search"
+		}
+		e.write(fmt.Sprintf("\r(%s)`%s': %s\x1b[K", label, string(query), match))
+
+		k, r, err := e.readKey()
+		if err != nil {
+			return false, err
+		}
+
+		switch k {
+		case keyRune:
+			query = append(query, r)
+			// 当前匹配项仍满足新查询时保留，否则继续向前查找
+			from := e.history.Len()
+			if matchIdx >= 0 {
+				from = matchIdx + 1
+			}
+			matchIdx = e.history.Search(string(query), from)
+		case keyBackspace:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			matchIdx = -1
+			if len(query) > 0 {
+				matchIdx = e.history.Search(string(query), e.history.Len())
+			}
+		case keySearch:
+			if len(query) == 0 {
+				continue
+			}
+			from := e.history.Len()
+			if matchIdx >= 0 {
+				from = matchIdx
+			}
+			if next := e.history.Search(string(query), from); next >= 0 {
+				matchIdx = next
+			}
+		case keyEnter:
+			if matchIdx >= 0 {
+				st.setBuf([]rune(e.history.At(matchIdx)))
+			}
+			e.refresh(st)
+			return true, nil
+		case keyCancel, keyInterrupt:
+			st.setBuf(original)
+			return false, nil
+		default:
+			// 其他按键接受当前匹配项并回到普通编辑模式
+			if matchIdx >= 0 {
+				st.setBuf([]rune(e.history.At(matchIdx)))
+				st.histIdx = matchIdx
+			}
+			return false, nil
+		}
+	}
+}
+
+// complete 补全光标前的单词：唯一候选项直接替换，多个候选项时补全公共前缀并列出候选项
+func (e *Editor) complete(st *lineState) {
+	if e.Completer == nil {
+		return
+	}
+
+	start := st.pos
+	for start > 0 && st.buf[start-1] != ' ' {
+		start--
+	}
+	word := string(st.buf[start:st.pos])
+	candidates := e.Completer(word)
+
+	switch len(candidates) {
+	case 0:
+		e.write("\a")
+		return
+	case 1:
+		replacement := candidates[0]
+		if !strings.HasSuffix(replacement, "/") {
+			replacement += " "
+		}
+		st.replace(start, replacement)
+		return
+	}
+
+	if prefix := commonPrefix(candidates); len(prefix) > len(word) {
+		st.replace(start, prefix)
+		return
+	}
+
+	// 列出候选项后在新行重绘输入
+	e.write("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+}
+
+// readKey 读取一个按键，解析常见的 ANSI 转义序列
+func (e *Editor) readKey() (key, rune, error) {
+	r, _, err := e.reader.ReadRune()
+	if err != nil {
+		return keyUnknown, 0, err
+	}
+
+	switch r {
+	case '\r', '\n':
+		return keyEnter, r, nil
+	case 1: // Ctrl-A
+		return keyHome, r, nil
+	case 2: // Ctrl-B
+		return keyLeft, r, nil
+	case 3: // Ctrl-C
+		return keyInterrupt, r, nil
+	case 4: // Ctrl-D
+		return keyEOF, r, nil
+	case 5: // Ctrl-E
+		return keyEnd, r, nil
+	case 6: // Ctrl-F
+		return keyRight, r, nil
+	case 7: // Ctrl-G
+		return keyCancel, r, nil
+	case 8, 127: // Ctrl-H / Backspace
+		return keyBackspace, r, nil
+	case 9: // Tab
+		return keyTab, r, nil
+	case 11: // Ctrl-K
+		return keyKillToEnd, r, nil
+	case 12: // Ctrl-L
+		return keyClear, r, nil
+	case 14: // Ctrl-N
+		return keyDown, r, nil
+	case 16: // Ctrl-P
+		return keyUp, r, nil
+	case 18: // Ctrl-R
+		return keySearch, r, nil
+	case 21: // Ctrl-U
+		return keyKillToStart, r, nil
+	case 23: // Ctrl-W
+		return keyKillWord, r, nil
+	case 27: // ESC
+		return e.readEscape()
+	}
+
+	if r < 32 {
+		return keyUnknown, r, nil
+	}
+	return keyRune, r, nil
+}
+
+// readEscape 解析 ESC 之后的控制序列
+func (e *Editor) readEscape() (key, rune, error) {
+	r, _, err := e.reader.ReadRune()
+	if err != nil {
+		return keyUnknown, 0, err
+	}
+	if r != '[' && r != 'O' {
+		return keyUnknown, r, nil
+	}
+
+	var seq []rune
+	for {
+		c, _, err := e.reader.ReadRune()
+		if err != nil {
+			return keyUnknown, 0, err
+		}
+		seq = append(seq, c)
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || c == '~' {
+			break
+		}
+	}
+
+	switch string(seq) {
+	case "A":
+		return keyUp, 0, nil
+	case "B":
+		return keyDown, 0, nil
+	case "C":
+		return keyRight, 0, nil
+	case "D":
+		return keyLeft, 0, nil
+	case "H", "1~", "7~":
+		return keyHome, 0, nil
+	case "F", "4~", "8~":
+		return keyEnd, 0, nil
+	case "3~":
+		return keyDelete, 0, nil
+	}
+	return keyUnknown, 0, nil
+}
+
+// refresh 重绘当前行并将光标移动到正确位置
+func (e *Editor) refresh(st *lineState) {
+	var sb strings.Builder
+	sb.WriteString("\r")
+	sb.WriteString(st.prompt)
+	sb.WriteString(string(st.buf))
+	sb.WriteString("\x1b[K")
+	if back := StringWidth(string(st.buf[st.pos:])); back > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dD", back)
+	}
+	e.write(sb.String())
+}
+
+// write 向终端输出内容
+func (e *Editor) write(s string) {
+	_, _ = e.out.WriteString(s)
+}
+
+// insert 在光标处插入字符
+func (st *lineState) insert(r rune) {
+	st.buf = append(st.buf, 0)
+	copy(st.buf[st.pos+1:], st.buf[st.pos:])
+	st.buf[st.pos] = r
+	st.pos++
+}
+
+// deleteAt 删除指定位置的字符
+func (st *lineState) deleteAt(i int) {
+	if i < 0 || i >= len(st.buf) {
+		return
+	}
+	st.buf = append(st.buf[:i], st.buf[i+1:]...)
+}
+
+// killWord 删除光标前的一个单词
+func (st *lineState) killWord() {
+	start := st.pos
+	for start > 0 && st.buf[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && st.buf[start-1] != ' ' {
+		start--
+	}
+	st.buf = append(st.buf[:start], st.buf[st.pos:]...)
+	st.pos = start
+}
+
+// replace 将 start 到光标之间的内容替换为 text，光标移到替换内容之后
+func (st *lineState) replace(start int, text string) {
+	tail := append([]rune{}, st.buf[st.pos:]...)
+	st.buf = append(append(st.buf[:start], []rune(text)...), tail...)
+	st.pos = start + len([]rune(text))
+}
+
+// commonPrefix 返回字符串列表的最长公共前缀
+func commonPrefix(items []string) string {
+	prefix := items[0]
+	for _, item := range items[1:] {
+		for !strings.HasPrefix(item, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// setBuf 替换编辑内容并将光标移到行尾
+func (st *lineState) setBuf(buf []rune) {
+	st.buf = append([]rune{}, buf...)
+	st.pos = len(st.buf)
+}
@@ -0,0 +1,30 @@
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal 判断文件是否连接到终端
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// SupportsColor 判断标准输出是否适合输出 ANSI 颜色。
+// 标准输出不是终端、设置了 NO_COLOR 或 TERM=dumb 时返回 false。
+func SupportsColor() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(os.Stdout)
+}
+
+// Width 返回终端的列数，文件不是终端时返回 0
+func Width(f *os.File) int {
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
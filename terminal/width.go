@@ -0,0 +1,45 @@
+package terminal
+
+import "unicode"
+
+// RuneWidth 返回字符在终端中占用的列数。
+// 中日韩文字及全角符号占两列，组合字符与控制字符占零列，其余占一列。
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.IsControl(r):
+		return 0
+	case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r):
+		return 0
+	case isWide(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// StringWidth 返回字符串在终端中占用的列数
+func StringWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// isWide 判断字符是否为宽字符
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115F) || // 韩文字母
+		(r >= 0x2E80 && r <= 0x303E) || // 中日韩部首、符号与标点
+		(r >= 0x3041 && r <= 0x33FF) || // 日文假名及中日韩兼容字符
+		(r >= 0x3400 && r <= 0x4DBF) || // 中日韩统一表意文字扩展 A
+		(r >= 0x4E00 && r <= 0x9FFF) || // 中日韩统一表意文字
+		(r >= 0xA000 && r <= 0xA4CF) || // 彝文
+		(r >= 0xAC00 && r <= 0xD7A3) || // 韩文音节
+		(r >= 0xF900 && r <= 0xFAFF) || // 中日韩兼容表意文字
+		(r >= 0xFE30 && r <= 0xFE4F) || // 中日韩兼容形式
+		(r >= 0xFF00 && r <= 0xFF60) || // 全角字符
+		(r >= 0xFFE0 && r <= 0xFFE6) || // 全角符号
+		(r >= 0x1F300 && r <= 0x1F64F) || // 表情符号
+		(r >= 0x1F900 && r <= 0x1F9FF) || // 补充表情符号
+		(r >= 0x20000 && r <= 0x3FFFD) // 中日韩统一表意文字扩展 B 及以后
+}
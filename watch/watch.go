@@ -0,0 +1,165 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultInterval = 2 * time.Second        // 默认轮询间隔
+	DefaultDebounce = 200 * time.Millisecond // 默认防抖时间，编辑器保存时往往会连续触发多个事件
+)
+
+// Watcher 监视文件与目录的变化，Linux 下使用 inotify，inotify 不可用时退回轮询。
+// 监视文件时实际监视其所在目录，因此先写临时文件再替换的保存方式也能被发现；
+// 监视目录时只关注目录中直接包含的文件。
+type Watcher struct {
+	targets    []string
+	Interval   time.Duration // 轮询间隔
+	Debounce   time.Duration // 防抖时间
+	usePolling bool          // 为 true 时直接使用轮询
+}
+
+// New 创建监视指定文件或目录的 Watcher，目标可以尚不存在。
+// param targets 为要监视的文件或目录路径。
+//
+// return 创建完成的 Watcher。
+func New(targets ...string) *Watcher {
+	cleaned := make([]string, 0, len(targets))
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		if t == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(t); err == nil {
+			t = abs
+		}
+		if !seen[t] {
+			seen[t] = true
+			cleaned = append(cleaned, t)
+		}
+	}
+	return &Watcher{
+		targets:  cleaned,
+		Interval: DefaultInterval,
+		Debounce: DefaultDebounce,
+	}
+}
+
+// Run 开始监视，阻塞直到 ctx 结束。
+// param ctx 用于停止监视。
+// param onChange 为变化回调，参数为防抖期间发生变化的目标，回调在 Run 所在的 goroutine 中依次执行。
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) {
+	events := make(chan string, 16)
+	go func() {
+		if !w.usePolling {
+			err := w.notify(ctx, events)
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+		}
+		w.poll(ctx, events)
+	}()
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case target := <-events:
+			pending[target] = true
+			timer.Reset(w.Debounce)
+		case <-timer.C:
+			changed := make([]string, 0, len(pending))
+			for t := range pending {
+				changed = append(changed, t)
+			}
+			sort.Strings(changed)
+			pending = make(map[string]bool)
+			onChange(changed)
+		}
+	}
+}
+
+// poll 定期比较目标的状态签名，发现变化时发送事件
+func (w *Watcher) poll(ctx context.Context, events chan<- string) {
+	signatures := make(map[string]string, len(w.targets))
+	for _, t := range w.targets {
+		signatures[t] = signature(t)
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, t := range w.targets {
+				if sig := signature(t); sig != signatures[t] {
+					signatures[t] = sig
+					select {
+					case events <- t:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// signature 返回文件或目录的状态签名，目录的签名包含其中所有文件的名称、大小与修改时间
+func signature(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "missing"
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano())
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "unreadable"
+	}
+	var sb strings.Builder
+	for _, entry := range entries {
+		if fi, err := entry.Info(); err == nil {
+			fmt.Fprintf(&sb, "%s/%d/%d;", entry.Name(), fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return sb.String()
+}
+
+// match 返回目录 dir 中的文件 name 发生变化时受影响的目标
+func (w *Watcher) match(dir, name string) []string {
+	path := filepath.Join(dir, name)
+	var matched []string
+	for _, t := range w.targets {
+		if t == path || t == dir {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+// watchDirs 返回需要监视的目录：目录目标本身，以及文件目标或尚不存在的目标所在的目录
+func (w *Watcher) watchDirs() []string {
+	var dirs []string
+	for _, t := range w.targets {
+		if info, err := os.Stat(t); err == nil && info.IsDir() {
+			dirs = append(dirs, t)
+		}
+		dirs = append(dirs, filepath.Dir(t))
+	}
+	return dirs
+}
@@ -0,0 +1,98 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask 关注的 inotify 事件
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// notify 使用 inotify 监视目标所在的目录，阻塞直到 ctx 结束。
+//
+// return inotify 不可用或读取失败时返回错误，调用方应退回轮询。
+func (w *Watcher) notify(ctx context.Context, events chan<- string) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("初始化 inotify 失败: %w", err)
+	}
+	defer unix.Close(fd)
+
+	dirs := make(map[int]string)
+	addWatches := func() error {
+		for _, dir := range w.watchDirs() {
+			wd, err := unix.InotifyAddWatch(fd, dir, inotifyMask)
+			if err != nil {
+				if err == unix.ENOENT {
+					continue // 目录尚不存在，创建后由上级目录的事件触发重新添加
+				}
+				return fmt.Errorf("监视目录失败 %s: %w", dir, err)
+			}
+			dirs[wd] = dir
+		}
+		return nil
+	}
+	if err := addWatches(); err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("没有可监视的目录")
+	}
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	pollFds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	for ctx.Err() == nil {
+		// 以较短的超时等待事件，以便及时响应 ctx 结束
+		n, err := unix.Poll(pollFds, 500)
+		if err == unix.EINTR || n == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("等待 inotify 事件失败: %w", err)
+		}
+
+		n, err = unix.Read(fd, buf)
+		if err == unix.EAGAIN || err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("读取 inotify 事件失败: %w", err)
+		}
+
+		created := false
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&unix.IN_CREATE != 0 || event.Mask&unix.IN_MOVED_TO != 0 {
+				created = true
+			}
+			dir, ok := dirs[int(event.Wd)]
+			if !ok || name == "" {
+				continue
+			}
+			for _, target := range w.match(dir, name) {
+				select {
+				case events <- target:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+		// 目标目录可能刚被创建，重新添加监视
+		if created {
+			if err := addWatches(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
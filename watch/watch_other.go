@@ -0,0 +1,13 @@
+//go:build !linux
+
+package watch
+
+import (
+	"context"
+	"errors"
+)
+
+// notify 非 Linux 平台不支持 inotify，直接返回错误以退回轮询
+func (w *Watcher) notify(ctx context.Context, events chan<- string) error {
+	return errors.New("当前平台不支持 inotify")
+}
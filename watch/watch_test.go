@@ -0,0 +1,76 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	for _, polling := range []bool{false, true} {
+		name := "notify"
+		if polling {
+			name = "polling"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "config.yaml")
+			promptDir := filepath.Join(dir, "prompts")
+			if err := os.WriteFile(configPath, []byte("a: 1\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			w := New(configPath, promptDir)
+			w.usePolling = polling
+			w.Interval = 50 * time.Millisecond
+			w.Debounce = 50 * time.Millisecond
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changes := make(chan []string, 8)
+			go w.Run(ctx, func(changed []string) { changes <- changed })
+			time.Sleep(200 * time.Millisecond)
+
+			// 先写临时文件再替换，与配置写入方式一致
+			tmp := configPath + ".tmp"
+			if err := os.WriteFile(tmp, []byte("a: 22\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Rename(tmp, configPath); err != nil {
+				t.Fatal(err)
+			}
+			expectChange(t, changes, configPath)
+
+			// 目录目标创建后，其中的文件变化也能被发现
+			if err := os.Mkdir(promptDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			expectChange(t, changes, promptDir)
+			time.Sleep(100 * time.Millisecond)
+			if err := os.WriteFile(filepath.Join(promptDir, "review.md"), []byte("review"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			expectChange(t, changes, promptDir)
+		})
+	}
+}
+
+// expectChange 等待包含指定目标的变化通知
+func expectChange(t *testing.T, changes <-chan []string, target string) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case changed := <-changes:
+			for _, c := range changed {
+				if c == target {
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatalf("no change reported for %s", target)
+		}
+	}
+}